@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+	"github.com/kashifsoofi/go-lox/internal/vm"
+)
+
+const benchUsage = `Usage: go-lox bench [flags] path...
+
+Runs each benchmark script several times on each backend and reports the
+mean and standard deviation of its running time and its allocations. A path
+may be a script or a directory of scripts, such as test/benchmark.
+
+Flags:`
+
+// benchBackends are the backends lox bench can measure, by name.
+var benchBackends = map[string]func(stdout io.Writer) runner{
+	"tree-walk": func(stdout io.Writer) runner {
+		return lox.NewInterpreter(lox.WithOutput(stdout), lox.WithCapabilities(lox.AllCapabilities...))
+	},
+	"vm": func(stdout io.Writer) runner {
+		return vm.NewVM(vm.WithOutput(stdout))
+	},
+}
+
+// benchResult is what one benchmark measured on one backend. Times are in
+// nanoseconds and allocations are per run.
+type benchResult struct {
+	Backend  string  `json:"backend"`
+	Name     string  `json:"name"`
+	Runs     int     `json:"runs"`
+	MeanNs   float64 `json:"meanNs"`
+	StddevNs float64 `json:"stddevNs"`
+	Allocs   uint64  `json:"allocs"`
+	Bytes    uint64  `json:"bytes"`
+}
+
+func (r benchResult) key() string {
+	return r.Backend + "/" + r.Name
+}
+
+// benchReport is the JSON form of a run of lox bench, which can be saved and
+// passed back with -baseline.
+type benchReport struct {
+	Benchmarks []benchResult `json:"benchmarks"`
+}
+
+// runBench is the bench subcommand. It returns the process exit code: 64 for
+// bad usage, a script's exit code if a benchmark fails and 1 if one regressed
+// against the baseline.
+func runBench(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	backends := flags.String("backends", "tree-walk,vm", "comma-separated `names` of the backends to run")
+	runs := flags.Int("n", 5, "how many times to run each benchmark")
+	jsonPath := flags.String("json", "", "write the results as JSON to `file`")
+	baselinePath := flags.String("baseline", "", "compare the results with the JSON in `file`")
+	threshold := flags.Float64("threshold", 0.1, "slowdown against the baseline, as a `fraction` of its mean, that counts as a regression")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, benchUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 64
+	}
+	if flags.NArg() == 0 || *runs < 1 {
+		flags.Usage()
+		return 64
+	}
+
+	var names []string
+	for _, name := range strings.Split(*backends, ",") {
+		if _, ok := benchBackends[name]; !ok {
+			fmt.Fprintf(stderr, "Unknown backend '%s'.\n", name)
+			return 64
+		}
+		names = append(names, name)
+	}
+
+	paths, err := benchPaths(flags.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 64
+	}
+
+	var baseline map[string]benchResult
+	if *baselinePath != "" {
+		if baseline, err = readBaseline(*baselinePath); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 64
+		}
+	}
+
+	// A benchmark that fails is left out of the report so the others still
+	// run, but its exit code is returned.
+	var report benchReport
+	failed := 0
+	for _, name := range names {
+		for _, path := range paths {
+			result, code := measure(name, path, *runs, stderr)
+			if code != 0 {
+				failed = code
+				continue
+			}
+			report.Benchmarks = append(report.Benchmarks, result)
+		}
+	}
+
+	regressed := printBench(stdout, report, baseline, *threshold)
+
+	if *jsonPath != "" {
+		if err := writeReport(*jsonPath, report); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 74
+		}
+	}
+
+	if failed != 0 {
+		return failed
+	}
+	if regressed {
+		return 1
+	}
+	return 0
+}
+
+// benchPaths expands directories in paths to the scripts in them.
+func benchPaths(paths []string) ([]string, error) {
+	var scripts []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("Can't open '%s'.", path)
+		}
+		if !info.IsDir() {
+			scripts = append(scripts, path)
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(path, "*.lox"))
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("No scripts in '%s'.", path)
+		}
+		scripts = append(scripts, matches...)
+	}
+	return scripts, nil
+}
+
+// measure runs the script at path the given number of times, each on a fresh
+// runner from the named backend. Output from the script is discarded.
+func measure(backend, path string, runs int, stderr io.Writer) (benchResult, int) {
+	result := benchResult{
+		Backend: backend,
+		Name:    strings.TrimSuffix(filepath.Base(path), ".lox"),
+		Runs:    runs,
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "Can't open '%s'.\n", path)
+		return result, 66
+	}
+
+	var before, after runtime.MemStats
+	times := make([]float64, runs)
+	for j := range times {
+		var output bytes.Buffer
+		r := benchBackends[backend](io.Discard)
+
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		code := runPath(r, path, string(source), &output)
+		times[j] = float64(time.Since(start))
+		runtime.ReadMemStats(&after)
+
+		if code != 0 {
+			// The first error is enough to say why; the rest can be seen
+			// by running the script.
+			first, _, _ := strings.Cut(output.String(), "\n")
+			fmt.Fprintf(stderr, "%s failed on %s: %s\n", path, backend, first)
+			return result, code
+		}
+		result.Allocs += after.Mallocs - before.Mallocs
+		result.Bytes += after.TotalAlloc - before.TotalAlloc
+	}
+
+	result.MeanNs, result.StddevNs = meanStddev(times)
+	result.Allocs /= uint64(runs)
+	result.Bytes /= uint64(runs)
+	return result, 0
+}
+
+// meanStddev returns the mean and sample standard deviation of xs.
+func meanStddev(xs []float64) (mean, stddev float64) {
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	if len(xs) < 2 {
+		return mean, 0
+	}
+
+	for _, x := range xs {
+		stddev += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(stddev / float64(len(xs)-1))
+}
+
+// printBench writes report as a table. Given a baseline, it adds how each
+// mean changed and reports whether any slowed down by more than threshold.
+func printBench(w io.Writer, report benchReport, baseline map[string]benchResult, threshold float64) bool {
+	regressed := false
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	header := "backend\tbenchmark\truns\tmean\tstddev\tallocs/run\tbytes/run"
+	if baseline != nil {
+		header += "\tvs baseline"
+	}
+	fmt.Fprintln(tw, header)
+
+	for _, result := range report.Benchmarks {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%v\t%v\t%d\t%d", result.Backend, result.Name, result.Runs,
+			roundDuration(result.MeanNs), roundDuration(result.StddevNs), result.Allocs, result.Bytes)
+		if baseline != nil {
+			base, ok := baseline[result.key()]
+			switch {
+			case !ok || base.MeanNs == 0:
+				fmt.Fprint(tw, "\tnew")
+			default:
+				change := (result.MeanNs - base.MeanNs) / base.MeanNs
+				fmt.Fprintf(tw, "\t%+.1f%%", change*100)
+				if change > threshold {
+					fmt.Fprint(tw, " REGRESSION")
+					regressed = true
+				}
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+	return regressed
+}
+
+// roundDuration shows a time in nanoseconds to a precision that suits it.
+func roundDuration(ns float64) time.Duration {
+	d := time.Duration(ns)
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond)
+	}
+	return d
+}
+
+func readBaseline(path string) (map[string]benchResult, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Can't open baseline '%s'.", path)
+	}
+
+	var report benchReport
+	if err := json.Unmarshal(content, &report); err != nil {
+		return nil, fmt.Errorf("Can't read baseline '%s': %s.", path, err)
+	}
+
+	baseline := make(map[string]benchResult, len(report.Benchmarks))
+	for _, result := range report.Benchmarks {
+		baseline[result.key()] = result
+	}
+	return baseline, nil
+}
+
+func writeReport(path string, report benchReport) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("Can't write '%s'.", path)
+	}
+	return nil
+}
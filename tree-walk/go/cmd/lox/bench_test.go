@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchmarkDir holds the benchmark programs of the shared Lox test suite.
+const benchmarkDir = testDir + "/benchmark"
+
+// benchmarkSkip maps a backend's name to the benchmark programs it can't run
+// and the reason.
+var benchmarkSkip = map[string]map[string]string{
+	"vm": {
+		"string_equality": "the VM does not share constants, so it has too many",
+	},
+}
+
+func BenchmarkTreeWalker(b *testing.B) {
+	benchmarkSuite(b, treeWalker)
+}
+
+func BenchmarkVM(b *testing.B) {
+	benchmarkSuite(b, bytecodeVM)
+}
+
+// benchmarkSuite runs each benchmark program as a sub-benchmark, so one can
+// be picked with -bench, as in -bench 'TreeWalker/fib'.
+func benchmarkSuite(b *testing.B, backend backend) {
+	paths, err := filepath.Glob(filepath.Join(benchmarkDir, "*.lox"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, path := range paths {
+		path := path
+		source, err := os.ReadFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(path), ".lox")
+		reason, skipped := benchmarkSkip[backend.name][name]
+		b.Run(name, func(b *testing.B) {
+			if skipped {
+				b.Skip(reason)
+			}
+			var stdout, stderr bytes.Buffer
+			b.ReportAllocs()
+			for j := 0; j < b.N; j++ {
+				stdout.Reset()
+				stderr.Reset()
+				if code := backend.run(path, string(source), &stdout, &stderr); code != 0 {
+					b.Fatalf("exit code %d: %s", code, stderr.String())
+				}
+			}
+		})
+	}
+}
+
+func TestMeanStddev(t *testing.T) {
+	mean, stddev := meanStddev([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	if mean != 5 {
+		t.Errorf("mean = %v, want 5", mean)
+	}
+	if want := math.Sqrt(32.0 / 7); math.Abs(stddev-want) > 1e-9 {
+		t.Errorf("stddev = %v, want %v", stddev, want)
+	}
+
+	if _, stddev := meanStddev([]float64{3}); stddev != 0 {
+		t.Errorf("stddev of one run = %v, want 0", stddev)
+	}
+}
+
+func TestBench(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "loop.lox")
+	if err := os.WriteFile(script, []byte("var i = 0; while (i < 100) i = i + 1; print i;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	results := filepath.Join(dir, "results.json")
+
+	var stdout, stderr bytes.Buffer
+	if code := runBench([]string{"-n", "3", "-json", results, dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	for _, row := range []string{"tree-walk  loop", "vm         loop"} {
+		if !strings.Contains(stdout.String(), row) {
+			t.Errorf("output has no row starting %q:\n%s", row, stdout.String())
+		}
+	}
+
+	content, err := os.ReadFile(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report benchReport
+	if err := json.Unmarshal(content, &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Benchmarks) != 2 {
+		t.Fatalf("got %d results, want 2", len(report.Benchmarks))
+	}
+	for _, result := range report.Benchmarks {
+		if result.Name != "loop" || result.Runs != 3 || result.MeanNs <= 0 || result.Allocs == 0 {
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+
+	// Against a baseline that ran in no time at all, every benchmark regressed.
+	for j := range report.Benchmarks {
+		report.Benchmarks[j].MeanNs = 1
+	}
+	baseline := filepath.Join(dir, "baseline.json")
+	if err := writeReport(baseline, report); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if code := runBench([]string{"-n", "1", "-backends", "tree-walk", "-baseline", baseline, script}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1 for a regression", code)
+	}
+	if !strings.Contains(stdout.String(), "REGRESSION") {
+		t.Errorf("regression not flagged:\n%s", stdout.String())
+	}
+
+	// A generous threshold lets the same run pass.
+	stdout.Reset()
+	if code := runBench([]string{"-n", "1", "-backends", "tree-walk", "-baseline", baseline, "-threshold", "1e12", script}, &stdout, &stderr); code != 0 {
+		t.Errorf("exit code %d, want 0:\n%s", code, stdout.String())
+	}
+
+	stderr.Reset()
+	if code := runBench([]string{"-backends", "jit", script}, &stdout, &stderr); code != 64 {
+		t.Errorf("exit code %d for an unknown backend, want 64", code)
+	}
+}
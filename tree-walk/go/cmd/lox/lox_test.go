@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+	"github.com/kashifsoofi/go-lox/internal/vm"
+)
+
+// testDir is the shared Lox test suite at the root of the repository.
+const testDir = "../../../../test"
+
+var (
+	expectedOutputRe       = regexp.MustCompile(`// expect: ?(.*)`)
+	expectedErrorRe        = regexp.MustCompile(`// (Error.*)`)
+	errorLineRe            = regexp.MustCompile(`// \[((java|c) )?line (\d+)\] (Error.*)`)
+	expectedRuntimeErrorRe = regexp.MustCompile(`// expect runtime error: (.+)`)
+	syntaxErrorRe          = regexp.MustCompile(`\[.*line (\d+)\] (Error.+)`)
+	stackTraceRe           = regexp.MustCompile(`\[line (\d+)\]`)
+)
+
+// backend is one way of running a Lox script, together with the parts of the
+// suite it does not support.
+type backend struct {
+	name string
+	// language selects which "[java line N]" or "[c line N]" annotations
+	// apply to this backend.
+	language string
+	run      func(path, source string, stdout, stderr *bytes.Buffer) int
+	// skip maps a test file or directory, relative to testDir, to the reason
+	// it is not run.
+	skip map[string]string
+}
+
+var treeWalker = backend{
+	name:     "tree-walk",
+	language: "java",
+	run: func(path, source string, stdout, stderr *bytes.Buffer) int {
+		// The module tests import files from the suite.
+		interpreter := lox.NewInterpreter(lox.WithOutput(stdout), lox.WithCapabilities(lox.CapClock, lox.CapFSRead+":"+testDir))
+		return runPath(interpreter, path, source, stderr)
+	},
+	skip: map[string]string{
+		"benchmark":                     "too slow for unit tests",
+		"expressions":                   "only meaningful for the chapter 7 interpreter",
+		"scanning":                      "only meaningful for the chapter 4 interpreter",
+		"limit/loop_too_large.lox":      "bytecode limit",
+		"limit/no_reuse_constants.lox":  "bytecode limit",
+		"limit/too_many_constants.lox":  "bytecode limit",
+		"limit/too_many_locals.lox":     "bytecode limit",
+		"limit/too_many_upvalues.lox":   "bytecode limit",
+		"for/statement_condition.lox":   "{} is an empty map literal",
+		"for/statement_increment.lox":   "{} is an empty map literal",
+		"for/statement_initializer.lox": "{} is an empty map literal",
+		"module/lib":                    "imported by the module tests",
+		"field/get_on_string.lox":       "strings have properties",
+	},
+}
+
+var bytecodeVM = backend{
+	name:     "vm",
+	language: "c",
+	run: func(path, source string, stdout, stderr *bytes.Buffer) int {
+		return run(vm.NewVM(vm.WithOutput(stdout)), source, stderr)
+	},
+	skip: map[string]string{
+		"benchmark":     "too slow for unit tests",
+		"expressions":   "only meaningful for the chapter 7 interpreter",
+		"scanning":      "only meaningful for the chapter 4 interpreter",
+		"list":          "not implemented by the VM",
+		"map":           "not implemented by the VM",
+		"break":         "not implemented by the VM",
+		"continue":      "not implemented by the VM",
+		"exception":     "not implemented by the VM",
+		"lambda":        "not implemented by the VM",
+		"module":        "not implemented by the VM",
+		"math":          "not implemented by the VM",
+		"string_method": "not implemented by the VM",
+	},
+}
+
+func TestTreeWalker(t *testing.T) {
+	runSuite(t, treeWalker)
+}
+
+func TestVM(t *testing.T) {
+	runSuite(t, bytecodeVM)
+}
+
+func runSuite(t *testing.T, b backend) {
+	err := filepath.Walk(testDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, _ := filepath.Rel(testDir, path)
+		name = filepath.ToSlash(name)
+		if reason, ok := b.skip[name]; ok {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			t.Run(name, func(t *testing.T) { t.Skip(reason) })
+			return nil
+		}
+
+		if info.IsDir() || filepath.Ext(path) != ".lox" {
+			return nil
+		}
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			runTest(t, b, path)
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// expectations are the results a test file declares in its comments.
+type expectations struct {
+	output           []string
+	compileErrors    []string
+	runtimeError     string
+	runtimeErrorLine int
+	exitCode         int
+}
+
+func parseExpectations(source, language string) expectations {
+	var e expectations
+
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+
+		if match := expectedOutputRe.FindStringSubmatch(text); match != nil {
+			e.output = append(e.output, match[1])
+			continue
+		}
+
+		if match := expectedErrorRe.FindStringSubmatch(text); match != nil {
+			e.compileErrors = append(e.compileErrors, fmt.Sprintf("[line %d] %s", line, match[1]))
+			e.exitCode = 65
+			continue
+		}
+
+		if match := errorLineRe.FindStringSubmatch(text); match != nil {
+			if match[2] == "" || match[2] == language {
+				e.compileErrors = append(e.compileErrors, fmt.Sprintf("[line %s] %s", match[3], match[4]))
+				e.exitCode = 65
+			}
+			continue
+		}
+
+		if match := expectedRuntimeErrorRe.FindStringSubmatch(text); match != nil {
+			e.runtimeError = match[1]
+			e.runtimeErrorLine = line
+			e.exitCode = 70
+		}
+	}
+
+	return e
+}
+
+func runTest(t *testing.T, b backend, path string) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := parseExpectations(string(source), b.language)
+
+	var stdout, stderr bytes.Buffer
+	exitCode := b.run(path, string(source), &stdout, &stderr)
+
+	errorLines := lines(stderr.String())
+	if expected.runtimeError != "" {
+		checkRuntimeError(t, expected, errorLines)
+	} else {
+		checkCompileErrors(t, expected, errorLines)
+	}
+
+	if exitCode != expected.exitCode {
+		t.Errorf("expected exit code %d, got %d", expected.exitCode, exitCode)
+	}
+
+	checkOutput(t, expected, lines(stdout.String()))
+}
+
+func checkRuntimeError(t *testing.T, expected expectations, errorLines []string) {
+	if len(errorLines) < 2 {
+		t.Errorf("expected runtime error %q and a stack trace, got %q", expected.runtimeError, errorLines)
+		return
+	}
+
+	if errorLines[0] != expected.runtimeError {
+		t.Errorf("expected runtime error %q, got %q", expected.runtimeError, errorLines[0])
+	}
+
+	for _, line := range errorLines[1:] {
+		if match := stackTraceRe.FindStringSubmatch(line); match != nil {
+			if n, _ := strconv.Atoi(match[1]); n != expected.runtimeErrorLine {
+				t.Errorf("expected runtime error on line %d, got line %d", expected.runtimeErrorLine, n)
+			}
+			return
+		}
+	}
+	t.Errorf("expected stack trace, got %q", errorLines[1:])
+}
+
+func checkCompileErrors(t *testing.T, expected expectations, errorLines []string) {
+	found := make([]string, 0)
+	for _, line := range errorLines {
+		// Indented lines add context to the error above them.
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		if match := syntaxErrorRe.FindStringSubmatch(line); match != nil {
+			found = append(found, fmt.Sprintf("[line %s] %s", match[1], match[2]))
+		} else {
+			t.Errorf("unexpected output on stderr: %q", line)
+		}
+	}
+
+	want := append([]string{}, expected.compileErrors...)
+	sort.Strings(want)
+	sort.Strings(found)
+	if strings.Join(want, "\n") != strings.Join(found, "\n") {
+		t.Errorf("expected errors:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(found, "\n"))
+	}
+}
+
+func checkOutput(t *testing.T, expected expectations, outputLines []string) {
+	for i, line := range outputLines {
+		if i >= len(expected.output) {
+			t.Errorf("got output %q when none was expected", line)
+			continue
+		}
+		if line != expected.output[i] {
+			t.Errorf("expected output %q on line %d, got %q", expected.output[i], i+1, line)
+		}
+	}
+
+	for i := len(outputLines); i < len(expected.output); i++ {
+		t.Errorf("missing expected output %q", expected.output[i])
+	}
+}
+
+// lines splits output into lines, dropping the empty line after the final
+// newline.
+func lines(output string) []string {
+	result := strings.Split(output, "\n")
+	if len(result) > 0 && result[len(result)-1] == "" {
+		result = result[:len(result)-1]
+	}
+	return result
+}
+
+// TestClock checks that clock() counts from the Unix epoch on both backends.
+func TestClock(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	runners := map[string]runner{
+		"tree-walk": lox.NewInterpreter(lox.WithOutput(&stdout)),
+		"vm":        vm.NewVM(vm.WithOutput(&stdout)),
+	}
+	for name, r := range runners {
+		stdout.Reset()
+		before := float64(time.Now().UnixNano()) / float64(time.Second)
+		if exitCode := run(r, "print clock();", &stderr); exitCode != 0 {
+			t.Fatalf("%s: exit code %d: %s", name, exitCode, stderr.String())
+		}
+		after := float64(time.Now().UnixNano()) / float64(time.Second)
+
+		clock, err := strconv.ParseFloat(strings.TrimSpace(stdout.String()), 64)
+		if err != nil || clock < before-1 || clock > after+1 {
+			t.Errorf("%s: expected clock() between %f and %f, got %q", name, before, after, stdout.String())
+		}
+	}
+}
@@ -1,92 +1,126 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"io"
 	"os"
 
 	"github.com/kashifsoofi/go-lox/internal/lox"
+	"github.com/kashifsoofi/go-lox/internal/vm"
 )
 
+// runner executes Lox source code. Both the tree-walking interpreter and the
+// bytecode VM implement it.
+type runner interface {
+	Run(source string) (lox.Value, []lox.Diagnostic, error)
+}
+
+// fileRunner is a runner that can load modules imported by a script, which it
+// finds relative to the script's path.
+type fileRunner interface {
+	RunFile(path string) (lox.Value, []lox.Diagnostic, error)
+}
+
 var (
-	interpreter *lox.Interpreter = lox.NewInterpreter()
+	useVM = flag.Bool("vm", false, "run scripts on the bytecode VM instead of the tree-walking interpreter")
 )
 
 func main() {
-	if len(os.Args) > 2 {
-		fmt.Println("Usage: go-lox [script]")
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		os.Exit(runBench(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	flag.Usage = func() {
+		fmt.Println("Usage: go-lox [--vm] [script]")
+		fmt.Println("       go-lox bench [flags] path...")
+	}
+	flag.Parse()
+
+	if flag.NArg() > 1 {
+		flag.Usage()
 		os.Exit(64)
 	}
 
-	if len(os.Args) == 2 {
-		runFile(os.Args[1])
+	newRunner := func() runner {
+		if *useVM {
+			return vm.NewVM()
+		}
+		// Scripts run from the command line are trusted.
+		return lox.NewInterpreter(lox.WithCapabilities(lox.AllCapabilities...))
+	}
+
+	if flag.NArg() == 1 {
+		runFile(newRunner(), flag.Arg(0))
 	} else {
-		runPrompt()
+		newREPL(newRunner, os.Stdin, os.Stdout, os.Stderr, defaultHistoryPath()).run()
 	}
 }
 
-func runFile(path string) {
+func runFile(interpreter runner, path string) {
 	bytes, err := os.ReadFile(path)
 	if err != nil {
 		fmt.Println("File not found.")
 		return
 	}
 
-	run(string(bytes))
-
-	if lox.HadError {
-		os.Exit(65)
-	}
-	if lox.HadRuntimeError {
-		os.Exit(70)
+	if code := runPath(interpreter, path, string(bytes), os.Stderr); code != 0 {
+		os.Exit(code)
 	}
 }
 
-func runPrompt() {
-	var reader = bufio.NewReader(os.Stdin)
-
-	for {
-		fmt.Print("> ")
+// run executes source, writes any errors to stderr and returns the process
+// exit code for the outcome: 65 for compile errors and 70 for a runtime error.
+func run(interpreter runner, source string, stderr io.Writer) int {
+	_, diagnostics, err := interpreter.Run(source)
+	return report(source, diagnostics, err, stderr)
+}
 
-		line, _, err := reader.ReadLine()
-		if err == io.EOF {
-			os.Exit(0)
-		} else if err != nil {
-			os.Exit(0)
-		}
+// runPath is run for source read from the file at path.
+func runPath(interpreter runner, path, source string, stderr io.Writer) int {
+	fr, ok := interpreter.(fileRunner)
+	if !ok {
+		return run(interpreter, source, stderr)
+	}
 
-		if len(line) == 0 {
-			continue
-		}
+	_, diagnostics, err := fr.RunFile(path)
+	return report(source, diagnostics, err, stderr)
+}
 
-		if string(line) == "exit" {
-			break
+func report(source string, diagnostics []lox.Diagnostic, err error, stderr io.Writer) int {
+	if len(diagnostics) > 0 {
+		for _, diagnostic := range diagnostics {
+			fmt.Fprintln(stderr, diagnostic)
+			printExcerpt(stderr, source, diagnostic)
 		}
-
-		run(string(line))
-		lox.HadError = false
-		lox.HadRuntimeError = false
+		return 65
 	}
-}
 
-func run(source string) {
-	scanner := lox.NewScanner(source)
-	tokens := scanner.ScanTokens()
-	parser := lox.NewParser(tokens)
-	statements := parser.Parse()
-
-	// Stop if there was a syntax error.
-	if lox.HadError {
-		return
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		printExcerpt(stderr, source, err)
+		return 70
 	}
 
-	resolver := lox.NewResolver(interpreter)
-	resolver.Resolve(statements)
+	return 0
+}
 
-	if lox.HadError {
+// printExcerpt shows the source an error points at, if it knows where that is.
+func printExcerpt(stderr io.Writer, source string, err error) {
+	spanned, ok := err.(interface{ Span() lox.Span })
+	if !ok {
 		return
 	}
 
-	interpreter.Interpret(statements)
+	// An error raised in an imported module points into that module's source.
+	if runtimeErr, ok := err.(*lox.RuntimeError); ok && runtimeErr.Path != "" {
+		module, readErr := os.ReadFile(runtimeErr.Path)
+		if readErr != nil {
+			return
+		}
+		source = string(module)
+	}
+	if excerpt := lox.Excerpt(source, spanned.Span()); excerpt != "" {
+		fmt.Fprintln(stderr, excerpt)
+	}
 }
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+// maxHistory is how many entries the history file keeps.
+const maxHistory = 1000
+
+const replHelp = `Enter Lox statements or expressions. Input continues over several lines
+until its parentheses, brackets and braces balance, and the value of an
+expression is printed.
+
+  :help         show this message
+  :reset        discard all definitions and start afresh
+  :load <file>  run a script in the current session
+  :ast <code>   print the syntax tree of code without running it
+  :history      list earlier input
+  :quit         leave the REPL (as does end of input)`
+
+// repl is the interactive prompt. Each entry runs in the same runner so
+// definitions carry over from one entry to the next.
+type repl struct {
+	newRunner   func() runner
+	runner      runner
+	in          *bufio.Scanner
+	stdout      io.Writer
+	stderr      io.Writer
+	historyPath string
+	history     []string
+}
+
+// newREPL creates a prompt reading from stdin. The last maxHistory entries
+// are kept in the file at historyPath, if it is not empty, so they survive
+// across sessions.
+func newREPL(newRunner func() runner, stdin io.Reader, stdout, stderr io.Writer, historyPath string) *repl {
+	r := &repl{
+		newRunner:   newRunner,
+		runner:      newRunner(),
+		in:          bufio.NewScanner(stdin),
+		stdout:      stdout,
+		stderr:      stderr,
+		historyPath: historyPath,
+	}
+	r.loadHistory()
+	return r
+}
+
+// defaultHistoryPath is ~/.lox_history, or empty if there is no home
+// directory.
+func defaultHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".lox_history")
+}
+
+func (r *repl) run() {
+	for {
+		entry, ok := r.read()
+		if !ok {
+			return
+		}
+
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		r.addHistory(entry)
+
+		if strings.HasPrefix(entry, ":") {
+			if !r.command(entry) {
+				return
+			}
+			continue
+		}
+
+		if strings.TrimSpace(entry) == "exit" {
+			return
+		}
+
+		r.eval(entry)
+	}
+}
+
+// read returns the next entry, reading more lines while it is unbalanced.
+func (r *repl) read() (string, bool) {
+	fmt.Fprint(r.stdout, "> ")
+
+	var entry strings.Builder
+	for r.in.Scan() {
+		entry.WriteString(r.in.Text())
+		if strings.HasPrefix(entry.String(), ":") || !incomplete(entry.String()) {
+			return entry.String(), true
+		}
+		entry.WriteString("\n")
+		fmt.Fprint(r.stdout, "... ")
+	}
+
+	fmt.Fprintln(r.stdout)
+	return entry.String(), entry.Len() > 0
+}
+
+// command runs a meta-command and reports whether the REPL should carry on.
+func (r *repl) command(entry string) bool {
+	name, arg, _ := strings.Cut(strings.TrimSpace(entry), " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case ":help":
+		fmt.Fprintln(r.stdout, replHelp)
+	case ":reset":
+		r.runner = r.newRunner()
+	case ":load":
+		source, err := os.ReadFile(arg)
+		if err != nil {
+			fmt.Fprintln(r.stderr, err)
+			return true
+		}
+		runPath(r.runner, arg, string(source), r.stderr)
+	case ":ast":
+		r.printAst(withSemicolon(arg))
+	case ":history":
+		for i, entry := range r.history {
+			fmt.Fprintf(r.stdout, "%4d  %s\n", i+1, strings.ReplaceAll(entry, "\n", "\n      "))
+		}
+	case ":quit":
+		return false
+	default:
+		fmt.Fprintf(r.stderr, "Unknown command %s. Type :help for a list.\n", name)
+	}
+	return true
+}
+
+// eval runs entry and prints the value of a trailing expression.
+func (r *repl) eval(entry string) {
+	source := withSemicolon(entry)
+	value, diagnostics, err := r.runner.Run(source)
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintln(r.stderr, diagnostic)
+		printExcerpt(r.stderr, source, diagnostic)
+	}
+	if err != nil {
+		fmt.Fprintln(r.stderr, err)
+		printExcerpt(r.stderr, source, err)
+		return
+	}
+
+	if value != nil {
+		fmt.Fprintln(r.stdout, lox.Stringify(value))
+	}
+}
+
+func (r *repl) printAst(source string) {
+	scanner := lox.NewScanner(source)
+	parser := lox.NewParser(scanner.ScanTokens())
+	statements := parser.Parse()
+
+	diagnostics := append(scanner.Errors(), parser.Errors()...)
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintln(r.stderr, diagnostic)
+		printExcerpt(r.stderr, source, diagnostic)
+	}
+	if len(diagnostics) > 0 {
+		return
+	}
+
+	printer := &lox.AstPrinter{}
+	for _, statement := range statements {
+		fmt.Fprintln(r.stdout, printer.PrintStmt(statement))
+	}
+}
+
+func (r *repl) loadHistory() {
+	if r.historyPath == "" {
+		return
+	}
+
+	f, err := os.Open(r.historyPath)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Entries are quoted so multi-line input takes one line of the file.
+		if entry, err := strconv.Unquote(scanner.Text()); err == nil {
+			r.history = append(r.history, entry)
+		}
+	}
+	if len(r.history) > maxHistory {
+		r.history = r.history[len(r.history)-maxHistory:]
+		r.saveHistory()
+	}
+}
+
+func (r *repl) addHistory(entry string) {
+	r.history = append(r.history, entry)
+	if r.historyPath == "" {
+		return
+	}
+	if len(r.history) > maxHistory {
+		r.history = r.history[len(r.history)-maxHistory:]
+		r.saveHistory()
+		return
+	}
+
+	f, err := os.OpenFile(r.historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, strconv.Quote(entry))
+}
+
+// saveHistory replaces the history file with the entries kept in memory, so
+// the file doesn't grow past maxHistory entries.
+func (r *repl) saveHistory() {
+	var contents strings.Builder
+	for _, entry := range r.history {
+		contents.WriteString(strconv.Quote(entry))
+		contents.WriteString("\n")
+	}
+	os.WriteFile(r.historyPath, []byte(contents.String()), 0600)
+}
+
+// incomplete reports whether source has unclosed parentheses, brackets,
+// braces or strings, and so needs more input.
+func incomplete(source string) bool {
+	depth := 0
+	inString := false
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case inString:
+			if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(source) && source[i+1] == '/':
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		}
+	}
+	return inString || depth > 0
+}
+
+// withSemicolon lets a bare expression be entered without its trailing ';'.
+func withSemicolon(source string) string {
+	trimmed := strings.TrimSpace(source)
+	if trimmed == "" || strings.HasSuffix(trimmed, ";") {
+		return source
+	}
+
+	tokens := lox.NewScanner(trimmed).ScanTokens()
+	if endsWithBlock(tokens) {
+		return source
+	}
+	// A statement starting with '{' is a block, so a map literal needs
+	// parentheses to be an expression.
+	end := ";"
+	if startsMap(tokens) {
+		source, end = "("+source, ");"
+	}
+
+	// Keep the semicolon out of a trailing comment.
+	lastLine := trimmed[strings.LastIndexByte(trimmed, '\n')+1:]
+	if strings.Contains(lastLine, "//") {
+		return source + "\n" + end
+	}
+	return source + end
+}
+
+// endsWithBlock reports whether tokens are a block or a declaration that
+// ends with one, which takes no ';' after its closing brace.
+func endsWithBlock(tokens []*lox.Token) bool {
+	if len(tokens) < 2 || tokens[len(tokens)-2].Type != lox.TokenTypeRightBrace {
+		return false
+	}
+	switch tokens[0].Type {
+	case lox.TokenTypeClass, lox.TokenTypeIf, lox.TokenTypeWhile, lox.TokenTypeFor, lox.TokenTypeTry:
+		return true
+	case lox.TokenTypeFun:
+		// Without a name, it is a lambda.
+		return tokens[1].Type == lox.TokenTypeIdentifier
+	case lox.TokenTypeLeftBrace:
+		return !startsMap(tokens)
+	}
+	return false
+}
+
+// startsMap reports whether tokens start with a map literal rather than a
+// block, which is when the first ':' comes before the first ';' or '}' of
+// the outermost braces.
+func startsMap(tokens []*lox.Token) bool {
+	if tokens[0].Type != lox.TokenTypeLeftBrace {
+		return false
+	}
+	depth := 0
+	for _, token := range tokens[1:] {
+		switch token.Type {
+		case lox.TokenTypeLeftParen, lox.TokenTypeLeftBracket, lox.TokenTypeLeftBrace:
+			depth++
+		case lox.TokenTypeRightParen, lox.TokenTypeRightBracket, lox.TokenTypeRightBrace:
+			if depth == 0 {
+				return false
+			}
+			depth--
+		case lox.TokenTypeColon:
+			if depth == 0 {
+				return true
+			}
+		case lox.TokenTypeSemicolon:
+			if depth == 0 {
+				return false
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+func TestREPL(t *testing.T) {
+	input := `fun add(a, b) {
+  return a + b;
+}
+add(1,
+  2)
+var greeting = "hi";
+greeting
+:reset
+greeting
+:ast print -1 + x.y;
+`
+	historyPath := filepath.Join(t.TempDir(), "history")
+
+	var stdout, stderr bytes.Buffer
+	newRunner := func() runner { return lox.NewInterpreter(lox.WithOutput(&stdout)) }
+	newREPL(newRunner, strings.NewReader(input), &stdout, &stderr, historyPath).run()
+
+	output := strings.ReplaceAll(stdout.String(), "... ", "")
+	output = strings.ReplaceAll(output, "> ", "")
+	want := "3\nhi\n(print (+ (- 1) (. x y)))\n\n"
+	if output != want {
+		t.Errorf("expected output %q, got %q", want, output)
+	}
+	if !strings.HasPrefix(stderr.String(), "Undefined variable 'greeting'.") {
+		t.Errorf("expected :reset to discard definitions, got %q", stderr.String())
+	}
+
+	// A new session sees the entries of the last one.
+	stdout.Reset()
+	newREPL(newRunner, strings.NewReader(":history\n"), &stdout, &stderr, historyPath).run()
+	if !strings.Contains(stdout.String(), "   1  fun add(a, b) {\n        return a + b;\n      }\n") {
+		t.Errorf("expected history from the previous session, got %q", stdout.String())
+	}
+
+	if _, err := os.Stat(historyPath); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "history")
+	var old strings.Builder
+	for i := 0; i < maxHistory+5; i++ {
+		fmt.Fprintln(&old, strconv.Quote(fmt.Sprintf("print %d;", i)))
+	}
+	if err := os.WriteFile(historyPath, []byte(old.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	newRunner := func() runner { return lox.NewInterpreter(lox.WithOutput(&stdout)) }
+	newREPL(newRunner, strings.NewReader("print \"new\";\n"), &stdout, &stderr, historyPath).run()
+
+	content, err := os.ReadFile(historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if len(entries) != maxHistory {
+		t.Fatalf("expected %d entries in the history file, got %d", maxHistory, len(entries))
+	}
+	if first, last := entries[0], entries[len(entries)-1]; first != `"print 6;"` || last != `"print \"new\";"` {
+		t.Errorf("expected the oldest entries to be dropped, got %s to %s", first, last)
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	tests := map[string]bool{
+		"print 1;":            false,
+		"fun f() {":           true,
+		"f(1,":                true,
+		`print "{";`:          false,
+		`print "unterminated`: true,
+		"{ // }":              true,
+	}
+	for source, want := range tests {
+		if got := incomplete(source); got != want {
+			t.Errorf("incomplete(%q) = %v, want %v", source, got, want)
+		}
+	}
+}
+
+func TestWithSemicolon(t *testing.T) {
+	tests := map[string]string{
+		"1 + 2":                  "1 + 2;",
+		"print 1;":               "print 1;",
+		"1 // one":               "1 // one\n;",
+		"{ print 1; }":           "{ print 1; }",
+		"{}":                     "{}",
+		"fun f() {}":             "fun f() {}",
+		"class A {}":             "class A {}",
+		"if (true) { print 1; }": "if (true) { print 1; }",
+		"fun () {}":              "fun () {};",
+		`{"a": 1}`:               `({"a": 1});`,
+		`{"a": 1} // map`:        "({\"a\": 1} // map\n);",
+	}
+	for source, want := range tests {
+		if got := withSemicolon(source); got != want {
+			t.Errorf("withSemicolon(%q) = %q, want %q", source, got, want)
+		}
+	}
+
+	// Both map literals and lambdas are echoed.
+	var stdout, stderr bytes.Buffer
+	newRunner := func() runner { return lox.NewInterpreter(lox.WithOutput(&stdout)) }
+	newREPL(newRunner, strings.NewReader("{\"a\": 1}\nfun () {}\n"), &stdout, &stderr, filepath.Join(t.TempDir(), "history")).run()
+	output := strings.ReplaceAll(stdout.String(), "> ", "")
+	if want := "{\"a\": 1}\n<fn anonymous>\n\n"; output != want || stderr.Len() > 0 {
+		t.Errorf("expected output %q, got %q and errors %q", want, output, stderr.String())
+	}
+}
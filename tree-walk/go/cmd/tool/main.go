@@ -20,9 +20,14 @@ func main() {
 		"Call",
 		"Get",
 		"Grouping",
+		"Index",
+		"Lambda",
+		"List",
 		"Literal",
 		"Logical",
+		"Map",
 		"Set",
+		"SetIndex",
 		"Super",
 		"This",
 		"Unary",
@@ -32,11 +37,16 @@ func main() {
 		"Assign":   {"Name *Token", "Value Expr"},
 		"Binary":   {"Left Expr", "Operator *Token", "Right Expr"},
 		"Call":     {"Callee Expr", "Paren *Token", "Arguments []Expr"},
-		"Get":      {"Object Expr", "Name *Token"},
+		"Get":      {"Object Expr", "Name *Token", "cache methodCache"},
 		"Grouping": {"Expression Expr"},
+		"Index":    {"Object Expr", "Bracket *Token", "Index Expr"},
+		"Lambda":   {"Function *Function"},
+		"List":     {"Bracket *Token", "Elements []Expr"},
 		"Literal":  {"Value any"},
 		"Logical":  {"Left Expr", "Operator *Token", "Right Expr"},
+		"Map":      {"Brace *Token", "Keys []Expr", "Values []Expr"},
 		"Set":      {"Object Expr", "Name *Token", "Value Expr"},
+		"SetIndex": {"Object Expr", "Bracket *Token", "Index Expr", "Value Expr"},
 		"Super":    {"Keyword *Token", "Method *Token"},
 		"This":     {"Keyword *Token"},
 		"Unary":    {"Operator *Token", "Right Expr"},
@@ -46,25 +56,35 @@ func main() {
 
 	stmtTypeNames := []string{
 		"Block",
+		"Break",
 		"Class",
+		"Continue",
 		"Expression",
 		"Function",
 		"If",
+		"Import",
 		"Print",
 		"Return",
+		"Throw",
+		"Try",
 		"Var",
 		"While",
 	}
 	stmtTypes := map[string][]string{
 		"Block":      {"Statements []Stmt"},
+		"Break":      {"Keyword *Token"},
 		"Class":      {"Name *Token", "Superclass *Variable", "Methods []*Function"},
+		"Continue":   {"Keyword *Token"},
 		"Expression": {"Expression Expr"},
 		"Function":   {"Name *Token", "Parameters []*Token", "Body []Stmt"},
 		"If":         {"Condition Expr", "ThenBranch Stmt", "ElseBranch Stmt"},
+		"Import":     {"Keyword *Token", "Path *Token", "Name *Token"},
 		"Print":      {"Expression Expr"},
 		"Return":     {"Keyword *Token", "Value Expr"},
+		"Throw":      {"Keyword *Token", "Value Expr"},
+		"Try":        {"Body []Stmt", "CatchName *Token", "CatchBody []Stmt", "FinallyBody []Stmt"},
 		"Var":        {"Name *Token", "Initializer Expr"},
-		"While":      {"Condition Expr", "Body Stmt"},
+		"While":      {"Condition Expr", "Body Stmt", "Increment Expr"},
 	}
 	generateAst(outputDir, "Stmt", stmtTypeNames, stmtTypes)
 }
@@ -85,6 +105,8 @@ func generateAst(outputDir, baseName string, typeNames []string, types map[strin
 
 	fmt.Fprintf(f, "type %s interface {\n", baseName)
 	fmt.Fprintf(f, "\tAccept(v %sVisitor) any\n", baseName)
+	fmt.Fprintf(f, "\tSpan() Span\n")
+	fmt.Fprintf(f, "\tsetSpan(span Span)\n")
 	fmt.Fprintf(f, "}\n")
 	fmt.Fprintln(f, "")
 
@@ -104,23 +126,32 @@ func generateVisitor(f *os.File, baseName string, typeNames []string) {
 
 func generateType(f *os.File, baseName, typeName string, fields []string) {
 	fmt.Fprintf(f, "type %s struct {\n", typeName)
+	// node records where in the source the syntax came from.
+	fmt.Fprintf(f, "\tnode\n")
 	for _, field := range fields {
 		fmt.Fprintf(f, "\t%s\n", field)
 	}
 	fmt.Fprintf(f, "}\n")
 	fmt.Fprintln(f, "")
-	// New func
+	// New func. Unexported fields hold state for the interpreter and start
+	// out zero.
+	var exported []string
+	for _, field := range fields {
+		if field[0] >= 'A' && field[0] <= 'Z' {
+			exported = append(exported, field)
+		}
+	}
 	fmt.Fprintf(f, "func New%s(", typeName)
-	for i, field := range fields {
+	for i, field := range exported {
 		fieldName, fieldType, _ := strings.Cut(field, " ")
 		fmt.Fprintf(f, "%s %s", strings.ToLower(fieldName), fieldType)
-		if i+1 < len(fields) {
+		if i+1 < len(exported) {
 			fmt.Fprintf(f, ", ")
 		}
 	}
 	fmt.Fprintf(f, ") *%s {\n", typeName)
 	fmt.Fprintf(f, "\treturn &%s{\n", typeName)
-	for _, field := range fields {
+	for _, field := range exported {
 		fieldName, _, _ := strings.Cut(field, " ")
 		fmt.Fprintf(f, "\t\t%s: %s,\n", fieldName, strings.ToLower(fieldName))
 	}
@@ -11,6 +11,120 @@ func (p *AstPrinter) Print(expression Expr) string {
 	return expression.Accept(p).(string)
 }
 
+// PrintStmt returns the syntax tree of statement in the same parenthesized
+// form Print uses for expressions.
+func (p *AstPrinter) PrintStmt(statement Stmt) string {
+	return statement.Accept(p).(string)
+}
+
+func (p *AstPrinter) VisitBlockStmt(stmt *Block) any {
+	return p.parenthesize2("block", stmt.Statements)
+}
+
+func (p *AstPrinter) VisitBreakStmt(stmt *Break) any {
+	return "(break)"
+}
+
+func (p *AstPrinter) VisitClassStmt(stmt *Class) any {
+	var builder strings.Builder
+	builder.WriteString("(class " + stmt.Name.Lexeme)
+	if stmt.Superclass != nil {
+		builder.WriteString(" < " + stmt.Superclass.Name.Lexeme)
+	}
+	for _, method := range stmt.Methods {
+		builder.WriteString(" " + p.PrintStmt(method))
+	}
+	builder.WriteString(")")
+
+	return builder.String()
+}
+
+func (p *AstPrinter) VisitContinueStmt(stmt *Continue) any {
+	return "(continue)"
+}
+
+func (p *AstPrinter) VisitExpressionStmt(stmt *Expression) any {
+	return p.parenthesize(";", stmt.Expression)
+}
+
+func (p *AstPrinter) VisitFunctionStmt(stmt *Function) any {
+	var builder strings.Builder
+	builder.WriteString("(fun ")
+	if stmt.Name != nil {
+		builder.WriteString(stmt.Name.Lexeme)
+	}
+	builder.WriteString("(")
+	for i, param := range stmt.Parameters {
+		if i > 0 {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(param.Lexeme)
+	}
+	builder.WriteString(")")
+	p.transform(&builder, stmt.Body)
+	builder.WriteString(")")
+
+	return builder.String()
+}
+
+func (p *AstPrinter) VisitIfStmt(stmt *If) any {
+	if stmt.ElseBranch == nil {
+		return p.parenthesize2("if", stmt.Condition, stmt.ThenBranch)
+	}
+
+	return p.parenthesize2("if-else", stmt.Condition, stmt.ThenBranch, stmt.ElseBranch)
+}
+
+func (p *AstPrinter) VisitImportStmt(stmt *Import) any {
+	return p.parenthesize2("import", stmt.Path, "as", stmt.Name)
+}
+
+func (p *AstPrinter) VisitPrintStmt(stmt *Print) any {
+	return p.parenthesize("print", stmt.Expression)
+}
+
+func (p *AstPrinter) VisitReturnStmt(stmt *Return) any {
+	if stmt.Value == nil {
+		return "(return)"
+	}
+
+	return p.parenthesize("return", stmt.Value)
+}
+
+func (p *AstPrinter) VisitThrowStmt(stmt *Throw) any {
+	return p.parenthesize("throw", stmt.Value)
+}
+
+func (p *AstPrinter) VisitTryStmt(stmt *Try) any {
+	var builder strings.Builder
+	builder.WriteString("(try " + p.parenthesize2("block", stmt.Body))
+	if stmt.CatchName != nil {
+		builder.WriteString(" " + p.parenthesize2("catch", stmt.CatchName, stmt.CatchBody))
+	}
+	if stmt.FinallyBody != nil {
+		builder.WriteString(" " + p.parenthesize2("finally", stmt.FinallyBody))
+	}
+	builder.WriteString(")")
+
+	return builder.String()
+}
+
+func (p *AstPrinter) VisitVarStmt(stmt *Var) any {
+	if stmt.Initializer == nil {
+		return p.parenthesize2("var", stmt.Name)
+	}
+
+	return p.parenthesize2("var", stmt.Name, "=", stmt.Initializer)
+}
+
+func (p *AstPrinter) VisitWhileStmt(stmt *While) any {
+	if stmt.Increment == nil {
+		return p.parenthesize2("while", stmt.Condition, stmt.Body)
+	}
+
+	return p.parenthesize2("while", stmt.Condition, stmt.Body, stmt.Increment)
+}
+
 func (p *AstPrinter) VisitAssignExpr(expr *Assign) any {
 	return p.parenthesize2("=", expr.Name.Lexeme, expr.Value)
 }
@@ -31,22 +145,52 @@ func (p *AstPrinter) VisitGroupingExpr(expr *Grouping) any {
 	return p.parenthesize("group", expr.Expression)
 }
 
+func (p *AstPrinter) VisitIndexExpr(expr *Index) any {
+	return p.parenthesize("[]", expr.Object, expr.Index)
+}
+
+func (p *AstPrinter) VisitLambdaExpr(expr *Lambda) any {
+	return p.PrintStmt(expr.Function)
+}
+
+func (p *AstPrinter) VisitListExpr(expr *List) any {
+	return p.parenthesize("list", expr.Elements...)
+}
+
 func (p *AstPrinter) VisitLiteralExpr(expr *Literal) any {
 	if expr.Value == nil {
 		return "nil"
 	}
+	if s, ok := expr.Value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
 
-	return fmt.Sprintf("%v", expr.Value)
+	return Stringify(expr.Value)
 }
 
 func (p *AstPrinter) VisitLogicalExpr(expr *Logical) any {
 	return p.parenthesize(expr.Operator.Lexeme, expr.Left, expr.Right)
 }
 
+func (p *AstPrinter) VisitMapExpr(expr *Map) any {
+	var builder strings.Builder
+	builder.WriteString("(map")
+	for i, key := range expr.Keys {
+		builder.WriteString(" " + p.parenthesize(":", key, expr.Values[i]))
+	}
+	builder.WriteString(")")
+
+	return builder.String()
+}
+
 func (p *AstPrinter) VisitSetExpr(expr *Set) any {
 	return p.parenthesize2("=", expr.Object, expr.Name.Lexeme, expr.Value)
 }
 
+func (p *AstPrinter) VisitSetIndexExpr(expr *SetIndex) any {
+	return p.parenthesize("[]=", expr.Object, expr.Index, expr.Value)
+}
+
 func (p *AstPrinter) VisitSuperExpr(expr *Super) any {
 	return p.parenthesize2("super", expr.Method)
 }
@@ -81,23 +225,36 @@ func (p *AstPrinter) parenthesize2(name string, parts ...any) string {
 	var builder strings.Builder
 	builder.WriteString("(")
 	builder.WriteString(name)
-	p.transform(builder, parts)
+	p.transform(&builder, parts...)
 	builder.WriteString(")")
 
 	return builder.String()
 }
 
-func (p *AstPrinter) transform(builder strings.Builder, parts ...any) {
+func (p *AstPrinter) transform(builder *strings.Builder, parts ...any) {
 	for _, part := range parts {
-		builder.WriteString(" ")
-		if expr, ok := part.(Expr); ok {
-			v, _ := expr.Accept(p).(string)
+		switch part := part.(type) {
+		case Expr:
+			builder.WriteString(" ")
+			v, _ := part.Accept(p).(string)
+			builder.WriteString(v)
+		case Stmt:
+			builder.WriteString(" ")
+			v, _ := part.Accept(p).(string)
 			builder.WriteString(v)
-		} else if _, ok := part.(Stmt); ok {
-			// TODO
-		} else if token, ok := part.(*Token); ok {
-			builder.WriteString(token.Lexeme)
-		} else {
+		case *Token:
+			builder.WriteString(" ")
+			builder.WriteString(part.Lexeme)
+		case []Expr:
+			for _, expr := range part {
+				p.transform(builder, expr)
+			}
+		case []Stmt:
+			for _, stmt := range part {
+				p.transform(builder, stmt)
+			}
+		default:
+			builder.WriteString(" ")
 			v, _ := part.(string)
 			builder.WriteString(v)
 		}
@@ -0,0 +1,201 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Capabilities a script can be granted. Each allows the native functions that
+// need it to be defined, and CapFSRead also allows importing modules. The file
+// system capabilities can be limited to a directory by appending it, as in
+// "fs:write:/tmp".
+//
+// CapEnv allows getenv(name), which returns the environment variable's value,
+// or nil if it is not set. CapExec allows exec(program, arguments), which runs
+// program with a list of string arguments and returns what it wrote to
+// standard output.
+const (
+	CapClock   = "clock"
+	CapEnv     = "env"
+	CapExec    = "exec"
+	CapFSRead  = "fs:read"
+	CapFSWrite = "fs:write"
+)
+
+// AllCapabilities grants scripts everything the host can do.
+var AllCapabilities = []string{CapClock, CapEnv, CapExec, CapFSRead, CapFSWrite}
+
+// nativeCapabilities maps each native function that needs a capability to
+// the capabilities that define it, so using one that was not granted can say
+// what is missing.
+var nativeCapabilities = map[string][]string{
+	"clock":  {CapClock},
+	"getenv": {CapEnv},
+	"exec":   {CapExec},
+
+	"readFile":   {CapFSRead},
+	"readLines":  {CapFSRead},
+	"listDir":    {CapFSRead},
+	"exists":     {CapFSRead},
+	"File":       {CapFSRead, CapFSWrite},
+	"writeFile":  {CapFSWrite},
+	"appendFile": {CapFSWrite},
+	"remove":     {CapFSWrite},
+}
+
+// capabilities maps each granted capability to the directories it is limited
+// to, or to nil if it is not limited.
+type capabilities map[string][]string
+
+// WithCapabilities grants scripts exactly the given capabilities instead of
+// the default, which is only CapClock. It panics if one is unknown.
+func WithCapabilities(granted ...string) Option {
+	return func(i *Interpreter) {
+		i.capabilities = capabilities{}
+		for _, capability := range granted {
+			i.capabilities.grant(capability)
+		}
+	}
+}
+
+func (c capabilities) grant(capability string) {
+	for _, name := range AllCapabilities {
+		if capability == name {
+			c[name] = nil
+			return
+		}
+
+		prefix := name + ":"
+		dir := strings.TrimPrefix(capability, prefix)
+		if strings.HasPrefix(capability, prefix) && (name == CapFSRead || name == CapFSWrite) && dir != "" {
+			// A capability without a directory is not limited.
+			if scopes, granted := c[name]; granted && scopes == nil {
+				return
+			}
+			c[name] = append(c[name], resolvePath(dir))
+			return
+		}
+	}
+
+	panic(fmt.Sprintf("lox: unknown capability %q", capability))
+}
+
+func (c capabilities) has(name string) bool {
+	_, ok := c[name]
+	return ok
+}
+
+func (c capabilities) hasAny(names []string) bool {
+	for _, name := range names {
+		if c.has(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPath panics unless capability allows access to path.
+func (i *Interpreter) checkPath(capability string, path string) {
+	i.checkPathAt(i.callSite(), capability, path)
+}
+
+// checkPathAt is checkPath reporting the error at token.
+func (i *Interpreter) checkPathAt(token *Token, capability string, path string) {
+	scopes, ok := i.capabilities[capability]
+	if ok && scopes == nil {
+		return
+	}
+
+	resolved := resolvePath(path)
+	for _, dir := range scopes {
+		if rel, err := filepath.Rel(dir, resolved); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return
+		}
+	}
+
+	panic(newRuntimeError(token, fmt.Sprintf("Missing capability '%s:%s'.", capability, resolved)))
+}
+
+// undefinedVariable panics for a global variable that is not defined.
+func (i *Interpreter) undefinedVariable(name *Token) {
+	if needed, ok := nativeCapabilities[name.Lexeme]; ok && !i.capabilities.hasAny(needed) {
+		panic(newRuntimeError(name, fmt.Sprintf("Missing capability '%s' for '%s'.", strings.Join(needed, "' or '"), name.Lexeme)))
+	}
+	panic(newRuntimeError(name, fmt.Sprintf("Undefined variable '%s'.", name.Lexeme)))
+}
+
+// resolvePath makes path absolute and resolves symbolic links in as much of
+// it as exists, so a link can't lead out of a directory a capability is
+// limited to.
+func resolvePath(path string) string {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	dir, rest := path, ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+}
+
+// defineCapabilityNatives defines the native functions the granted
+// capabilities allow.
+func (i *Interpreter) defineCapabilityNatives() {
+	if i.capabilities.has(CapClock) {
+		i.defineClock()
+	}
+	if i.capabilities.has(CapEnv) {
+		i.DefineNative("getenv", 1, func(args []Value) (Value, error) {
+			name, ok := args[0].(string)
+			if !ok {
+				return nil, errors.New("Variable name must be a string.")
+			}
+			if value, ok := os.LookupEnv(name); ok {
+				return value, nil
+			}
+			return nil, nil
+		})
+	}
+	if i.capabilities.has(CapExec) {
+		i.DefineNative("exec", 2, runCommand)
+	}
+	i.defineFileNatives()
+}
+
+// runCommand runs a program with a list of arguments and returns what it
+// wrote to standard output.
+func runCommand(args []Value) (Value, error) {
+	name, ok := args[0].(string)
+	if !ok {
+		return nil, errors.New("Command must be a string.")
+	}
+	list, ok := args[1].(*loxList)
+	if !ok {
+		return nil, errors.New("Arguments must be a list of strings.")
+	}
+	arguments := make([]string, len(list.elements))
+	for j, element := range list.elements {
+		if arguments[j], ok = element.(string); !ok {
+			return nil, errors.New("Arguments must be a list of strings.")
+		}
+	}
+
+	output, err := exec.Command(name, arguments...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("Command '%s' failed: %s.", name, err)
+	}
+	return string(output), nil
+}
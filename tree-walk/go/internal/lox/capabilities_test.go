@@ -0,0 +1,183 @@
+package lox_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+func TestCapabilities(t *testing.T) {
+	t.Setenv("LOX_TEST", "granted")
+
+	tests := []struct {
+		name         string
+		capabilities []string
+		source       string
+		output       string
+		message      string
+	}{
+		{
+			name:   "default grants clock",
+			source: "print clock() > 0;",
+			output: "true\n",
+		},
+		{
+			name:    "default denies env",
+			source:  `getenv("LOX_TEST");`,
+			message: "Missing capability 'env' for 'getenv'.",
+		},
+		{
+			name:         "env",
+			capabilities: []string{lox.CapEnv},
+			source:       `print getenv("LOX_TEST"); print getenv("LOX_TEST_UNSET");`,
+			output:       "granted\nnil\n",
+		},
+		{
+			name:         "clock not granted",
+			capabilities: []string{lox.CapEnv},
+			source:       "clock();",
+			message:      "Missing capability 'clock' for 'clock'.",
+		},
+		{
+			name:         "exec",
+			capabilities: []string{lox.CapExec},
+			source:       `print exec("echo", ["a", "b"]);`,
+			output:       "a b\n\n",
+		},
+		{
+			name:    "default denies exec",
+			source:  `exec("echo", []);`,
+			message: "Missing capability 'exec' for 'exec'.",
+		},
+		{
+			name:         "exec failure",
+			capabilities: []string{lox.CapExec},
+			source:       `exec("false", []);`,
+			message:      "Command 'false' failed: exit status 1.",
+		},
+		{
+			name:         "File needs a file system capability",
+			capabilities: []string{lox.CapClock},
+			source:       `File("x", "r");`,
+			message:      "Missing capability 'fs:read' or 'fs:write' for 'File'.",
+		},
+		{
+			name:         "scripts can define the names",
+			capabilities: []string{},
+			source:       `fun getenv(name) { return name; } print getenv("x");`,
+			output:       "x\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			options := []lox.Option{lox.WithOutput(&stdout)}
+			if test.capabilities != nil {
+				options = append(options, lox.WithCapabilities(test.capabilities...))
+			}
+
+			_, _, err := lox.NewInterpreter(options...).Run(test.source)
+			if test.message == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+			} else if runtimeErr, ok := err.(*lox.RuntimeError); !ok || runtimeErr.Message != test.message {
+				t.Fatalf("expected runtime error %q, got %v", test.message, err)
+			}
+			if stdout.String() != test.output {
+				t.Errorf("expected output %q, got %q", test.output, stdout.String())
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected an unknown capability to panic")
+		}
+	}()
+	lox.NewInterpreter(lox.WithCapabilities("network"))
+}
+
+func TestImportCapability(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "main.lox")
+	if err := os.WriteFile(script, []byte(`import "lib.lox" as lib;
+print lib.name;`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	module := filepath.Join(dir, "lib.lox")
+	if err := os.WriteFile(module, []byte(`var name = "lib";`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(dir, "secret.txt")
+
+	tests := []struct {
+		name         string
+		capabilities []string
+		source       string
+		output       string
+		message      string
+	}{
+		{
+			name:    "default denies import",
+			message: "Missing capability 'fs:read:" + module + "'.",
+		},
+		{
+			name:         "fs:read allows import",
+			capabilities: []string{lox.CapFSRead},
+			output:       "lib\n",
+		},
+		{
+			name:         "scoped to the directory",
+			capabilities: []string{lox.CapFSRead + ":" + dir},
+			output:       "lib\n",
+		},
+		{
+			name:         "scoped elsewhere",
+			capabilities: []string{lox.CapFSRead + ":" + filepath.Join(dir, "other")},
+			message:      "Missing capability 'fs:read:" + module + "'.",
+		},
+		{
+			// The check comes before the file is opened, so a missing
+			// file can't be told apart from one that exists.
+			name:    "denied before opening",
+			source:  `import "` + secret + `" as secret;`,
+			message: "Missing capability 'fs:read:" + secret + "'.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			options := []lox.Option{lox.WithOutput(&stdout)}
+			if test.capabilities != nil {
+				options = append(options, lox.WithCapabilities(test.capabilities...))
+			}
+			interpreter := lox.NewInterpreter(options...)
+
+			var err error
+			if test.source != "" {
+				_, _, err = interpreter.Run(test.source)
+			} else {
+				_, _, err = interpreter.RunFile(script)
+			}
+			if test.message == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+			} else if runtimeErr, ok := err.(*lox.RuntimeError); !ok || runtimeErr.Message != test.message {
+				t.Fatalf("expected runtime error %q, got %v", test.message, err)
+			}
+			if stdout.String() != test.output {
+				t.Errorf("expected output %q, got %q", test.output, stdout.String())
+			}
+		})
+	}
+}
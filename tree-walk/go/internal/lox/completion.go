@@ -0,0 +1,17 @@
+package lox
+
+// completion is how a statement finished executing. Anything but
+// completionNormal makes the enclosing statements stop early and pass it on
+// until a statement that handles it: a loop for break and continue, a call
+// for return, and a try statement for throw.
+type completion uint8
+
+const (
+	completionNormal completion = iota
+	completionBreak
+	completionContinue
+	// completionReturn leaves the value returned in Interpreter.returnValue.
+	completionReturn
+	// completionThrow leaves the error thrown in Interpreter.thrown.
+	completionThrow
+)
@@ -2,54 +2,81 @@ package lox
 
 import "fmt"
 
+// environment holds the variables of one scope. Global variables are looked
+// up by name. Local variables are kept in slots numbered by the Resolver in
+// the order they are declared, so they are defined in that order too.
 type environment struct {
 	enclosing *environment
-	values    map[string]any
+	// values holds the variables of a global environment by the symbols of
+	// their names, which strings interns.
+	values  map[*symbol]any
+	strings *stringTable
+	// slots holds the variables of a local environment.
+	slots []any
 }
 
-func newEnvironment(enclosing *environment) *environment {
+func newGlobalEnvironment(enclosing *environment, table *stringTable) *environment {
 	return &environment{
 		enclosing: enclosing,
-		values:    make(map[string]any),
+		values:    make(map[*symbol]any),
+		strings:   table,
+	}
+}
+
+// newEnvironment creates a local environment with room for size variables.
+func newEnvironment(enclosing *environment, size int) *environment {
+	return &environment{
+		enclosing: enclosing,
+		slots:     make([]any, 0, size),
 	}
 }
 
 func (e *environment) define(name string, value any) {
-	e.values[name] = value
+	if e.values != nil {
+		e.values[e.strings.symbol(name)] = value
+		return
+	}
+	e.slots = append(e.slots, value)
 }
 
 func (e *environment) assign(name *Token, value any) {
-	if _, ok := e.values[name.Lexeme]; ok {
-		e.values[name.Lexeme] = value
+	key := e.strings.lookup(name)
+	if _, ok := e.values[key]; ok {
+		e.values[key] = value
 		return
 	}
 
+	// Every module's globals enclose the same builtins, so assigning to a
+	// builtin defines the name in the module instead of changing it for all
+	// of them.
 	if e.enclosing != nil {
-		e.enclosing.assign(name, value)
-		return
+		if _, ok := e.enclosing.lookup(name); ok {
+			e.values[key] = value
+			return
+		}
 	}
 
 	panic(newRuntimeError(name, fmt.Sprintf("Undefined variable '%s'.", name.Lexeme)))
 }
 
-func (e *environment) get(name *Token) any {
-	if value, ok := e.values[name.Lexeme]; ok {
-		return value
-	}
-
-	if e.enclosing != nil {
-		return e.enclosing.get(name)
+// lookup returns the value of the variable called name in e or an enclosing
+// environment, and whether there is one.
+func (e *environment) lookup(name *Token) (any, bool) {
+	key := e.strings.lookup(name)
+	for environment := e; environment != nil; environment = environment.enclosing {
+		if value, ok := environment.values[key]; ok {
+			return value, true
+		}
 	}
-
-	panic(newRuntimeError(name, fmt.Sprintf("Undefined variable '%s'.", name.Lexeme)))
+	return nil, false
 }
 
-func (e *environment) getAt(distance int, name string) any {
-	return e.ancestor(distance).values[name]
+func (e *environment) getAt(distance, slot int) any {
+	return e.ancestor(distance).slots[slot]
 }
 
-func (e *environment) assignAt(distance int, name *Token, value any) {
-	e.ancestor(distance).values[name.Lexeme] = value
+func (e *environment) assignAt(distance, slot int, value any) {
+	e.ancestor(distance).slots[slot] = value
 }
 
 func (e *environment) ancestor(distance int) *environment {
@@ -1,33 +1,17 @@
 package lox
 
-import (
-	"fmt"
-	"os"
-)
-
-var (
-	HadError        bool = false
-	HadRuntimeError bool = false
-)
-
-func error(line int, message string) {
-	report(line, "", message)
+// Diagnostic is an error found while scanning, parsing or resolving a script,
+// before any of it is executed.
+type Diagnostic interface {
+	error
+	Position() (line int, lexeme string)
+	// Span returns the source text the error is about.
+	Span() Span
 }
 
-func errorWithToken(token *Token, message string) {
+func where(token *Token) string {
 	if token.Type == TokenTypeEOF {
-		report(token.Line, " at end", message)
-	} else {
-		report(token.Line, " at '"+token.Lexeme+"'", message)
+		return " at end"
 	}
-}
-
-func reportRuntimeError(err runtimeError) {
-	fmt.Fprintf(os.Stderr, "%v", err.Error())
-	HadRuntimeError = true
-}
-
-func report(line int, where, message string) {
-	fmt.Fprintf(os.Stderr, "[line %d] Error%s: %s\n", line, where, message)
-	HadError = true
+	return " at '" + token.Lexeme + "'"
 }
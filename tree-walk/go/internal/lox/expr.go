@@ -6,9 +6,14 @@ type ExprVisitor interface {
 	VisitCallExpr(expr *Call) any
 	VisitGetExpr(expr *Get) any
 	VisitGroupingExpr(expr *Grouping) any
+	VisitIndexExpr(expr *Index) any
+	VisitLambdaExpr(expr *Lambda) any
+	VisitListExpr(expr *List) any
 	VisitLiteralExpr(expr *Literal) any
 	VisitLogicalExpr(expr *Logical) any
+	VisitMapExpr(expr *Map) any
 	VisitSetExpr(expr *Set) any
+	VisitSetIndexExpr(expr *SetIndex) any
 	VisitSuperExpr(expr *Super) any
 	VisitThisExpr(expr *This) any
 	VisitUnaryExpr(expr *Unary) any
@@ -17,9 +22,12 @@ type ExprVisitor interface {
 
 type Expr interface {
 	Accept(v ExprVisitor) any
+	Span() Span
+	setSpan(span Span)
 }
 
 type Assign struct {
+	node
 	Name  *Token
 	Value Expr
 }
@@ -36,6 +44,7 @@ func (expr *Assign) Accept(v ExprVisitor) any {
 }
 
 type Binary struct {
+	node
 	Left     Expr
 	Operator *Token
 	Right    Expr
@@ -54,6 +63,7 @@ func (expr *Binary) Accept(v ExprVisitor) any {
 }
 
 type Call struct {
+	node
 	Callee    Expr
 	Paren     *Token
 	Arguments []Expr
@@ -72,8 +82,10 @@ func (expr *Call) Accept(v ExprVisitor) any {
 }
 
 type Get struct {
+	node
 	Object Expr
 	Name   *Token
+	cache  methodCache
 }
 
 func NewGet(object Expr, name *Token) *Get {
@@ -88,6 +100,7 @@ func (expr *Get) Accept(v ExprVisitor) any {
 }
 
 type Grouping struct {
+	node
 	Expression Expr
 }
 
@@ -101,7 +114,59 @@ func (expr *Grouping) Accept(v ExprVisitor) any {
 	return v.VisitGroupingExpr(expr)
 }
 
+type Index struct {
+	node
+	Object  Expr
+	Bracket *Token
+	Index   Expr
+}
+
+func NewIndex(object Expr, bracket *Token, index Expr) *Index {
+	return &Index{
+		Object:  object,
+		Bracket: bracket,
+		Index:   index,
+	}
+}
+
+func (expr *Index) Accept(v ExprVisitor) any {
+	return v.VisitIndexExpr(expr)
+}
+
+type Lambda struct {
+	node
+	Function *Function
+}
+
+func NewLambda(function *Function) *Lambda {
+	return &Lambda{
+		Function: function,
+	}
+}
+
+func (expr *Lambda) Accept(v ExprVisitor) any {
+	return v.VisitLambdaExpr(expr)
+}
+
+type List struct {
+	node
+	Bracket  *Token
+	Elements []Expr
+}
+
+func NewList(bracket *Token, elements []Expr) *List {
+	return &List{
+		Bracket:  bracket,
+		Elements: elements,
+	}
+}
+
+func (expr *List) Accept(v ExprVisitor) any {
+	return v.VisitListExpr(expr)
+}
+
 type Literal struct {
+	node
 	Value any
 }
 
@@ -116,6 +181,7 @@ func (expr *Literal) Accept(v ExprVisitor) any {
 }
 
 type Logical struct {
+	node
 	Left     Expr
 	Operator *Token
 	Right    Expr
@@ -133,7 +199,27 @@ func (expr *Logical) Accept(v ExprVisitor) any {
 	return v.VisitLogicalExpr(expr)
 }
 
+type Map struct {
+	node
+	Brace  *Token
+	Keys   []Expr
+	Values []Expr
+}
+
+func NewMap(brace *Token, keys []Expr, values []Expr) *Map {
+	return &Map{
+		Brace:  brace,
+		Keys:   keys,
+		Values: values,
+	}
+}
+
+func (expr *Map) Accept(v ExprVisitor) any {
+	return v.VisitMapExpr(expr)
+}
+
 type Set struct {
+	node
 	Object Expr
 	Name   *Token
 	Value  Expr
@@ -151,7 +237,29 @@ func (expr *Set) Accept(v ExprVisitor) any {
 	return v.VisitSetExpr(expr)
 }
 
+type SetIndex struct {
+	node
+	Object  Expr
+	Bracket *Token
+	Index   Expr
+	Value   Expr
+}
+
+func NewSetIndex(object Expr, bracket *Token, index Expr, value Expr) *SetIndex {
+	return &SetIndex{
+		Object:  object,
+		Bracket: bracket,
+		Index:   index,
+		Value:   value,
+	}
+}
+
+func (expr *SetIndex) Accept(v ExprVisitor) any {
+	return v.VisitSetIndexExpr(expr)
+}
+
 type Super struct {
+	node
 	Keyword *Token
 	Method  *Token
 }
@@ -168,6 +276,7 @@ func (expr *Super) Accept(v ExprVisitor) any {
 }
 
 type This struct {
+	node
 	Keyword *Token
 }
 
@@ -182,6 +291,7 @@ func (expr *This) Accept(v ExprVisitor) any {
 }
 
 type Unary struct {
+	node
 	Operator *Token
 	Right    Expr
 }
@@ -198,6 +308,7 @@ func (expr *Unary) Accept(v ExprVisitor) any {
 }
 
 type Variable struct {
+	node
 	Name *Token
 }
 
@@ -0,0 +1,228 @@
+package lox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// defineFileNatives defines the file system functions the fs:read and
+// fs:write capabilities allow.
+func (i *Interpreter) defineFileNatives() {
+	canRead := i.capabilities.has(CapFSRead)
+	canWrite := i.capabilities.has(CapFSWrite)
+
+	if canRead {
+		i.definePathNative("readFile", 1, CapFSRead, func(path string, args []Value) (Value, error) {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fileError("read", path, err)
+			}
+			return string(content), nil
+		})
+		i.definePathNative("readLines", 1, CapFSRead, func(path string, args []Value) (Value, error) {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fileError("read", path, err)
+			}
+			text := strings.TrimSuffix(string(content), "\n")
+			lines := make([]any, 0)
+			if text != "" {
+				for _, line := range strings.Split(text, "\n") {
+					lines = append(lines, strings.TrimSuffix(line, "\r"))
+				}
+			}
+			return newLoxList(lines), nil
+		})
+		i.definePathNative("listDir", 1, CapFSRead, func(path string, args []Value) (Value, error) {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return nil, fileError("list", path, err)
+			}
+			names := make([]any, len(entries))
+			for j, entry := range entries {
+				names[j] = entry.Name()
+			}
+			return newLoxList(names), nil
+		})
+		i.definePathNative("exists", 1, CapFSRead, func(path string, args []Value) (Value, error) {
+			_, err := os.Stat(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				return false, nil
+			}
+			if err != nil {
+				return nil, fileError("check", path, err)
+			}
+			return true, nil
+		})
+	}
+
+	if canWrite {
+		i.definePathNative("writeFile", 2, CapFSWrite, func(path string, args []Value) (Value, error) {
+			return nil, writeFile(path, args[1], os.O_CREATE|os.O_TRUNC|os.O_WRONLY)
+		})
+		i.definePathNative("appendFile", 2, CapFSWrite, func(path string, args []Value) (Value, error) {
+			return nil, writeFile(path, args[1], os.O_CREATE|os.O_APPEND|os.O_WRONLY)
+		})
+		i.definePathNative("remove", 1, CapFSWrite, func(path string, args []Value) (Value, error) {
+			if err := os.Remove(path); err != nil {
+				return nil, fileError("remove", path, err)
+			}
+			return nil, nil
+		})
+	}
+
+	if canRead || canWrite {
+		i.builtins.define("File", fileClass{})
+	}
+}
+
+// definePathNative defines a native function whose first argument is a path
+// that capability must allow access to.
+func (i *Interpreter) definePathNative(name string, arity int, capability string, fn func(path string, args []Value) (Value, error)) {
+	i.DefineNative(name, arity, func(args []Value) (Value, error) {
+		path, ok := args[0].(string)
+		if !ok {
+			return nil, errors.New("Path must be a string.")
+		}
+		i.checkPath(capability, path)
+		return fn(path, args)
+	})
+}
+
+func writeFile(path string, content Value, flag int) error {
+	text, ok := content.(string)
+	if !ok {
+		return errors.New("File content must be a string.")
+	}
+
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return fileError("write", path, err)
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return fileError("write", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fileError("write", path, err)
+	}
+	return nil
+}
+
+// fileError describes an operating system error in the way Lox runtime errors
+// are worded.
+func fileError(action, path string, err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	return fmt.Errorf("Can't %s '%s': %s.", action, path, err)
+}
+
+// fileClass is the built-in File class. File(path, mode) opens the file at
+// path for reading with mode "r", writing with "w" or appending with "a".
+type fileClass struct{}
+
+func (c fileClass) arity() int {
+	return 2
+}
+
+func (c fileClass) call(interpreter *Interpreter, arguments []any) any {
+	site := interpreter.callSite()
+	path, ok := arguments[0].(string)
+	if !ok {
+		panic(newRuntimeError(site, "Path must be a string."))
+	}
+
+	var flag int
+	switch arguments[1] {
+	case "r":
+		interpreter.checkPath(CapFSRead, path)
+		flag = os.O_RDONLY
+	case "w":
+		interpreter.checkPath(CapFSWrite, path)
+		flag = os.O_CREATE | os.O_TRUNC | os.O_WRONLY
+	case "a":
+		interpreter.checkPath(CapFSWrite, path)
+		flag = os.O_CREATE | os.O_APPEND | os.O_WRONLY
+	default:
+		panic(newRuntimeError(site, "File mode must be \"r\", \"w\" or \"a\"."))
+	}
+
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		panic(newRuntimeError(site, fileError("open", path, err).Error()))
+	}
+	interpreter.allocate(site)
+	return &loxFile{path: path, file: f, reader: bufio.NewReader(f)}
+}
+
+func (c fileClass) String() string {
+	return "File"
+}
+
+// loxFile is an open file, created by the File class.
+type loxFile struct {
+	path   string
+	file   *os.File
+	reader *bufio.Reader
+	closed bool
+}
+
+func (f *loxFile) get(name *Token) any {
+	switch name.Lexeme {
+	case "path":
+		return f.path
+	case "readLine":
+		return newNativeFunction("readLine", 0, func(interpreter *Interpreter, arguments []any) any {
+			f.checkOpen(interpreter)
+			line, err := f.reader.ReadString('\n')
+			if err == io.EOF && line == "" {
+				return nil
+			}
+			if err != nil && err != io.EOF {
+				panic(newRuntimeError(interpreter.callSite(), fileError("read", f.path, err).Error()))
+			}
+			interpreter.allocate(interpreter.callSite())
+			return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		})
+	case "write":
+		return newNativeFunction("write", 1, func(interpreter *Interpreter, arguments []any) any {
+			f.checkOpen(interpreter)
+			text, ok := arguments[0].(string)
+			if !ok {
+				panic(newRuntimeError(interpreter.callSite(), "File content must be a string."))
+			}
+			if _, err := f.file.WriteString(text); err != nil {
+				panic(newRuntimeError(interpreter.callSite(), fileError("write", f.path, err).Error()))
+			}
+			return nil
+		})
+	case "close":
+		return newNativeFunction("close", 0, func(interpreter *Interpreter, arguments []any) any {
+			f.checkOpen(interpreter)
+			f.closed = true
+			if err := f.file.Close(); err != nil {
+				panic(newRuntimeError(interpreter.callSite(), fileError("close", f.path, err).Error()))
+			}
+			return nil
+		})
+	}
+
+	panic(newRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme)))
+}
+
+func (f *loxFile) checkOpen(interpreter *Interpreter) {
+	if f.closed {
+		panic(newRuntimeError(interpreter.callSite(), "File is closed."))
+	}
+}
+
+func (f *loxFile) String() string {
+	return "<file " + f.path + ">"
+}
@@ -0,0 +1,96 @@
+package lox_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+func TestFileNatives(t *testing.T) {
+	dir := t.TempDir()
+	var stdout bytes.Buffer
+	interpreter := lox.NewInterpreter(lox.WithOutput(&stdout), lox.WithCapabilities(lox.CapFSRead, lox.CapFSWrite))
+
+	source := fmt.Sprintf(`var dir = %q;
+var path = dir + "/notes.txt";
+// Lox strings have no escapes, but can span lines.
+var nl = "
+";
+print exists(path);
+writeFile(path, "one" + nl + "two" + nl);
+appendFile(path, "three" + nl);
+print exists(path);
+print readFile(path);
+print readLines(path);
+print listDir(dir);
+
+var f = File(path, "r");
+var line = f.readLine();
+while (line != nil) {
+  print line;
+  line = f.readLine();
+}
+f.close();
+
+f = File(dir + "/out.txt", "w");
+f.write("written");
+f.close();
+print readFile(dir + "/out.txt");
+
+remove(path);
+print exists(path);
+f.write("again");`, dir)
+
+	_, _, err := interpreter.Run(source)
+	want := "false\ntrue\none\ntwo\nthree\n\n[\"one\", \"two\", \"three\"]\n[\"notes.txt\"]\none\ntwo\nthree\nwritten\nfalse\n"
+	if stdout.String() != want {
+		t.Errorf("expected output %q, got %q", want, stdout.String())
+	}
+	runtimeErr, ok := err.(*lox.RuntimeError)
+	if !ok || runtimeErr.Message != "File is closed." || runtimeErr.Token.Line != 29 {
+		t.Errorf("expected closed file error on line 29, got %v", err)
+	}
+
+	missing := filepath.Join(dir, "missing.txt")
+	_, _, err = interpreter.Run(fmt.Sprintf("\nreadFile(%q);", missing))
+	runtimeErr, ok = err.(*lox.RuntimeError)
+	if want := fmt.Sprintf("Can't read '%s': no such file or directory.", missing); !ok || runtimeErr.Message != want || runtimeErr.Token.Line != 2 {
+		t.Errorf("expected %q on line 2, got %v", want, err)
+	}
+}
+
+func TestFileCapabilities(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A link inside the directory can't be used to get out of it.
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	interpreter := lox.NewInterpreter(lox.WithOutput(&stdout), lox.WithCapabilities(lox.CapFSWrite+":"+dir))
+	inside := filepath.Join(dir, "ok.txt")
+	if _, _, err := interpreter.Run(fmt.Sprintf("writeFile(%q, \"ok\");", inside)); err != nil {
+		t.Errorf("expected writing inside %s to be allowed, got %v", dir, err)
+	}
+
+	resolvedOutside, _ := filepath.EvalSymlinks(outside)
+	for source, message := range map[string]string{
+		fmt.Sprintf("writeFile(%q, \"x\");", filepath.Join(outside, "x.txt")):     fmt.Sprintf("Missing capability 'fs:write:%s'.", filepath.Join(resolvedOutside, "x.txt")),
+		fmt.Sprintf("writeFile(%q, \"x\");", filepath.Join(dir, "link", "x.txt")): fmt.Sprintf("Missing capability 'fs:write:%s'.", filepath.Join(resolvedOutside, "x.txt")),
+		fmt.Sprintf("File(%q, \"r\");", filepath.Join(outside, "secret.txt")):     fmt.Sprintf("Missing capability 'fs:read:%s'.", filepath.Join(resolvedOutside, "secret.txt")),
+		fmt.Sprintf("readFile(%q);", filepath.Join(outside, "secret.txt")):        "Missing capability 'fs:read' for 'readFile'.",
+	} {
+		_, _, err := interpreter.Run(source)
+		if runtimeErr, ok := err.(*lox.RuntimeError); !ok || runtimeErr.Message != message {
+			t.Errorf("%s: expected runtime error %q, got %v", source, message, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package lox
+
+import "fmt"
+
+// frame is a call in progress, kept so runtime errors can report the Lox call
+// stack.
+type frame struct {
+	// function is the name of the function or method being called.
+	function string
+	// class is the class a method belongs to, if any.
+	class string
+	// site is the closing parenthesis of the call expression.
+	site *Token
+}
+
+func newFrame(callee LoxCallable, site *Token) frame {
+	switch callee := callee.(type) {
+	case *loxFunction:
+		return frame{function: callee.name(), class: callee.class, site: site}
+	case *nativeFunction:
+		return frame{function: callee.name, site: site}
+	case *loxClass:
+		if callee.findMethod("init") != nil {
+			return frame{function: "init", class: callee.name, site: site}
+		}
+		return frame{function: callee.name, site: site}
+	}
+	return frame{function: fmt.Sprint(callee), site: site}
+}
+
+func (f frame) name() string {
+	if f.class != "" {
+		return f.class + "." + f.function
+	}
+	return f.function
+}
+
+// stackTrace lists the active calls, innermost first, given the line the error
+// occurred on. The outermost entry is the top level of the script.
+func stackTrace(frames []frame, line int) []StackFrame {
+	trace := make([]StackFrame, 0, len(frames)+1)
+	for j := len(frames) - 1; j >= 0; j-- {
+		trace = append(trace, StackFrame{Function: frames[j].name(), Line: line})
+		line = frames[j].site.Line
+	}
+	return append(trace, StackFrame{Function: "script", Line: line})
+}
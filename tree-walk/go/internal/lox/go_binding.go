@@ -0,0 +1,401 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Bind makes a Go function or a pointer to a Go struct available to every
+// module as a global.
+//
+// A function can be called from Lox. Its arguments are converted from Lox
+// values to the parameter types and its results back again: numbers to and
+// from any numeric type, strings, booleans, nil, lists to and from slices and
+// arrays, and maps to and from maps. If its last result is an error, a
+// non-nil error becomes a runtime error at the call. Several other results
+// are returned as a list.
+//
+// A struct is an object whose exported fields can be read and assigned and
+// whose exported methods can be called, by their Go names. Slices and maps
+// are copied when they cross into Lox, but structs are shared.
+func (i *Interpreter) Bind(name string, value any) error {
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Kind() == reflect.Func && !v.IsNil():
+		i.builtins.define(name, newGoFunction(name, v))
+	case v.Kind() == reflect.Pointer && !v.IsNil() && v.Elem().Kind() == reflect.Struct:
+		i.builtins.define(name, goObject{pointer: value})
+	default:
+		return fmt.Errorf("lox: can't bind %T to %s: need a function or a pointer to a struct", value, name)
+	}
+	return nil
+}
+
+// goObject is a pointer to a Go struct. It is compared by the pointer, so two
+// goObjects for the same struct are equal.
+type goObject struct {
+	pointer any
+}
+
+func (o goObject) get(name *Token) any {
+	v := reflect.ValueOf(o.pointer)
+	if field, ok := v.Elem().Type().FieldByName(name.Lexeme); ok && field.IsExported() {
+		value := v.Elem().FieldByIndex(field.Index)
+		// Share a nested struct rather than copying it, so assigning to
+		// its fields changes the original.
+		if value.Kind() == reflect.Struct {
+			return goObject{pointer: value.Addr().Interface()}
+		}
+		return toLoxValue(value)
+	}
+
+	if method := v.MethodByName(name.Lexeme); method.IsValid() {
+		return newGoFunction(name.Lexeme, method)
+	}
+
+	panic(newRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme)))
+}
+
+func (o goObject) set(name *Token, value any) {
+	v := reflect.ValueOf(o.pointer).Elem()
+	field, ok := v.Type().FieldByName(name.Lexeme)
+	if !ok || !field.IsExported() {
+		panic(newRuntimeError(name, fmt.Sprintf("Undefined field '%s'.", name.Lexeme)))
+	}
+
+	converted, err := fromLoxValue(value, field.Type)
+	if err != nil {
+		panic(newRuntimeError(name, fmt.Sprintf("Can't assign to field '%s': %s.", name.Lexeme, err)))
+	}
+	v.FieldByIndex(field.Index).Set(converted)
+}
+
+func (o goObject) String() string {
+	return reflect.TypeOf(o.pointer).Elem().Name() + " instance"
+}
+
+// newGoFunction wraps fn so Lox code can call it.
+func newGoFunction(name string, fn reflect.Value) *nativeFunction {
+	t := fn.Type()
+	arity := t.NumIn()
+	if t.IsVariadic() {
+		arity = variadic
+	}
+
+	return newNativeFunction(name, arity, func(interpreter *Interpreter, arguments []any) any {
+		result, err := callGo(fn, arguments)
+		if err != nil {
+			panic(newRuntimeError(interpreter.callSite(), err.Error()))
+		}
+		interpreter.allocateResult(interpreter.callSite(), result)
+		return result
+	})
+}
+
+// callGo calls fn with arguments converted to its parameter types and
+// returns its results as a Lox value.
+func callGo(fn reflect.Value, arguments []any) (any, error) {
+	t := fn.Type()
+	if t.IsVariadic() && len(arguments) < t.NumIn()-1 {
+		return nil, fmt.Errorf("Expected at least %d arguments but got %d.", t.NumIn()-1, len(arguments))
+	}
+
+	in := make([]reflect.Value, len(arguments))
+	for j, argument := range arguments {
+		var paramType reflect.Type
+		if t.IsVariadic() && j >= t.NumIn()-1 {
+			paramType = t.In(t.NumIn() - 1).Elem()
+		} else {
+			paramType = t.In(j)
+		}
+
+		value, err := fromLoxValue(argument, paramType)
+		if err != nil {
+			return nil, fmt.Errorf("Argument %d: %s.", j+1, err)
+		}
+		in[j] = value
+	}
+
+	out := fn.Call(in)
+	if len(out) > 0 && t.Out(len(out)-1) == errorType {
+		if err, _ := out[len(out)-1].Interface().(error); err != nil {
+			return nil, err
+		}
+		out = out[:len(out)-1]
+	}
+
+	switch len(out) {
+	case 0:
+		return nil, nil
+	case 1:
+		return toLoxValue(out[0]), nil
+	}
+	results := make([]any, len(out))
+	for j, result := range out {
+		results[j] = toLoxValue(result)
+	}
+	return newLoxList(results), nil
+}
+
+// toLoxValue converts a Go value to the Lox value that represents it.
+func toLoxValue(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+
+	// Lox values that passed through Go unchanged.
+	if v.CanInterface() {
+		switch value := v.Interface().(type) {
+		case *loxInstance, *loxClass, *loxFunction, *nativeFunction, *loxList, *loxMap, *loxModule, goObject:
+			return value
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		elements := make([]any, v.Len())
+		for j := range elements {
+			elements[j] = toLoxValue(v.Index(j))
+		}
+		return newLoxList(elements)
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		// Go maps have no order, so give the Lox map a predictable one.
+		keys := v.MapKeys()
+		sort.Slice(keys, func(a, b int) bool {
+			return fmt.Sprint(keys[a]) < fmt.Sprint(keys[b])
+		})
+		m := newLoxMap()
+		for _, key := range keys {
+			// A NaN key can't be looked up, in Go or in Lox, so it is
+			// left out.
+			k := toLoxValue(key)
+			if n, ok := k.(float64); ok && math.IsNaN(n) {
+				continue
+			}
+			m.put(k, toLoxValue(v.MapIndex(key)))
+		}
+		return m
+	case reflect.Func:
+		if v.IsNil() {
+			return nil
+		}
+		return newGoFunction("native", v)
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Elem().Kind() == reflect.Struct {
+			return goObject{pointer: v.Interface()}
+		}
+		return toLoxValue(v.Elem())
+	case reflect.Struct:
+		// Copy the struct so the object has a pointer to share.
+		pointer := reflect.New(v.Type())
+		pointer.Elem().Set(v)
+		return goObject{pointer: pointer.Interface()}
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return toLoxValue(v.Elem())
+	}
+
+	return fmt.Sprint(v)
+}
+
+// fromLoxValue converts a Lox value to a Go value of type t.
+func fromLoxValue(value any, t reflect.Type) (reflect.Value, error) {
+	mismatch := func() error {
+		return fmt.Errorf("expected %s but got %s", t, typeName(value))
+	}
+
+	if value == nil {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, mismatch()
+	}
+
+	if object, ok := value.(goObject); ok {
+		v := reflect.ValueOf(object.pointer)
+		switch {
+		case v.Type().AssignableTo(t):
+			return v, nil
+		case v.Elem().Type().AssignableTo(t):
+			return v.Elem(), nil
+		}
+		return reflect.Value{}, mismatch()
+	}
+
+	if t.Kind() == reflect.Interface {
+		converted, err := toGoValue(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v := reflect.ValueOf(converted)
+		if !v.Type().AssignableTo(t) {
+			return reflect.Value{}, mismatch()
+		}
+		result := reflect.New(t).Elem()
+		result.Set(v)
+		return result, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, ok := value.(bool); ok {
+			return reflect.ValueOf(b).Convert(t), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := value.(float64); ok {
+			// Converting a number out of range is implementation-defined,
+			// so check the range first. 2^(bits-1) is exact as a float64.
+			limit := math.Ldexp(1, t.Bits()-1)
+			if n != math.Trunc(n) || n < -limit || n >= limit {
+				return reflect.Value{}, fmt.Errorf("expected %s but got %s", t, Stringify(n))
+			}
+			return reflect.ValueOf(int64(n)).Convert(t), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n, ok := value.(float64); ok {
+			if n < 0 || n != math.Trunc(n) || n >= math.Ldexp(1, t.Bits()) {
+				return reflect.Value{}, fmt.Errorf("expected %s but got %s", t, Stringify(n))
+			}
+			return reflect.ValueOf(uint64(n)).Convert(t), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, ok := value.(float64); ok {
+			return reflect.ValueOf(n).Convert(t), nil
+		}
+	case reflect.String:
+		if s, ok := value.(string); ok {
+			return reflect.ValueOf(s).Convert(t), nil
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := value.(*loxList)
+		if !ok {
+			break
+		}
+		var result reflect.Value
+		if t.Kind() == reflect.Slice {
+			result = reflect.MakeSlice(t, len(list.elements), len(list.elements))
+		} else if len(list.elements) == t.Len() {
+			result = reflect.New(t).Elem()
+		} else {
+			return reflect.Value{}, fmt.Errorf("expected %s but got a list of %d elements", t, len(list.elements))
+		}
+		for j, element := range list.elements {
+			converted, err := fromLoxValue(element, t.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d %w", j, err)
+			}
+			result.Index(j).Set(converted)
+		}
+		return result, nil
+	case reflect.Map:
+		m, ok := value.(*loxMap)
+		if !ok {
+			break
+		}
+		result := reflect.MakeMapWithSize(t, len(m.keys))
+		for _, key := range m.keys {
+			convertedKey, err := fromLoxValue(key, t.Key())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("key %s %w", stringifyElement(key, map[any]bool{}), err)
+			}
+			convertedValue, err := fromLoxValue(m.entries[key], t.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("value of key %s %w", stringifyElement(key, map[any]bool{}), err)
+			}
+			result.SetMapIndex(convertedKey, convertedValue)
+		}
+		return result, nil
+	}
+
+	return reflect.Value{}, mismatch()
+}
+
+// toGoValue converts a Lox value to the Go value a host would expect in an
+// interface: lists become []any, maps map[any]any and Go objects their
+// pointers. A map with a list or map as a key has no such Go value.
+func toGoValue(value any) (any, error) {
+	switch value := value.(type) {
+	case *loxList:
+		elements := make([]any, len(value.elements))
+		for j, element := range value.elements {
+			converted, err := toGoValue(element)
+			if err != nil {
+				return nil, fmt.Errorf("element %d %w", j, err)
+			}
+			elements[j] = converted
+		}
+		return elements, nil
+	case *loxMap:
+		m := make(map[any]any, len(value.keys))
+		for _, key := range value.keys {
+			name := stringifyElement(key, map[any]bool{})
+			convertedKey, err := toGoValue(key)
+			if err != nil {
+				return nil, fmt.Errorf("key %s %w", name, err)
+			}
+			if convertedKey != nil && !reflect.TypeOf(convertedKey).Comparable() {
+				return nil, fmt.Errorf("key %s can't be the key of a Go map", name)
+			}
+			convertedValue, err := toGoValue(value.entries[key])
+			if err != nil {
+				return nil, fmt.Errorf("value of key %s %w", name, err)
+			}
+			m[convertedKey] = convertedValue
+		}
+		return m, nil
+	case goObject:
+		return value.pointer, nil
+	}
+	return value, nil
+}
+
+// typeName is how error messages refer to the type of a Lox value.
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	case string:
+		return "a string"
+	case *loxList:
+		return "a list"
+	case *loxMap:
+		return "a map"
+	case *loxFunction, *nativeFunction:
+		return "a function"
+	case *loxClass:
+		return "a class"
+	case *loxModule:
+		return "a module"
+	}
+	return "an instance"
+}
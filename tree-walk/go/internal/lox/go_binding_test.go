@@ -0,0 +1,129 @@
+package lox_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+type point struct {
+	X, Y   float64
+	Label  string
+	hidden int
+}
+
+func (p *point) Move(dx, dy float64) {
+	p.X += dx
+	p.Y += dy
+}
+
+func (p point) Coords() []float64 {
+	return []float64{p.X, p.Y}
+}
+
+type shape struct {
+	Origin point
+	Points []*point
+}
+
+func TestBind(t *testing.T) {
+	var stdout bytes.Buffer
+	interpreter := lox.NewInterpreter(lox.WithOutput(&stdout))
+
+	origin := &point{X: 1, Y: 2}
+	bindings := map[string]any{
+		"origin": origin,
+		"shape":  &shape{},
+		"add":    func(a, b int) int { return a + b },
+		"join":   func(sep string, parts ...string) string { return strings.Join(parts, sep) },
+		"counts": func(words []string) map[string]int {
+			counts := map[string]int{}
+			for _, word := range words {
+				counts[word]++
+			}
+			return counts
+		},
+		"divide": func(a, b float64) (float64, error) {
+			if b == 0 {
+				return 0, errors.New("Division by zero.")
+			}
+			return a / b, nil
+		},
+		"newPoint": func(x, y float64) point { return point{X: x, Y: y} },
+		"small":    func(n int8) int8 { return n },
+		"big":      func(n int64) int64 { return n },
+		"unsigned": func(n uint32) uint32 { return n },
+		"describe": func(v any) string { return fmt.Sprint(v) },
+	}
+	for name, value := range bindings {
+		if err := interpreter.Bind(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := interpreter.Bind("answer", 42); err == nil {
+		t.Error("expected an error binding a number")
+	}
+
+	_, diagnostics, err := interpreter.Run(`print origin;
+print origin.X + origin.Y;
+origin.Move(2, 3);
+origin.Label = "o";
+print origin.Coords();
+print add(2, 3);
+print join("-", "a", "b", "c");
+print counts(["a", "b", "a"]);
+print divide(1, 4);
+var p = newPoint(5, 6);
+p.X = 7;
+print p.Coords();
+shape.Origin.X = 9;
+shape.Points = [origin, p];
+print shape.Points.length;
+print shape.Points[0] == origin;
+divide(1, 0);`)
+	if len(diagnostics) > 0 {
+		t.Fatalf("unexpected diagnostics %v", diagnostics)
+	}
+
+	want := "point instance\n3\n[3, 5]\n5\na-b-c\n{\"a\": 2, \"b\": 1}\n0.25\n[7, 6]\n2\ntrue\n"
+	if stdout.String() != want {
+		t.Errorf("expected output %q, got %q", want, stdout.String())
+	}
+	if origin.X != 3 || origin.Y != 5 || origin.Label != "o" {
+		t.Errorf("expected origin to be moved and labelled, got %+v", origin)
+	}
+
+	runtimeErr, ok := err.(*lox.RuntimeError)
+	if !ok {
+		t.Fatalf("expected a runtime error, got %v", err)
+	}
+	if runtimeErr.Message != "Division by zero." || runtimeErr.Token.Line != 17 {
+		t.Errorf("expected division error on line 17, got %q on line %d", runtimeErr.Message, runtimeErr.Token.Line)
+	}
+
+	for source, message := range map[string]string{
+		"add(1.5, 2);":                "Argument 1: expected int but got 1.5.",
+		`add("1", 2);`:                "Argument 1: expected int but got a string.",
+		"join();":                     "Expected at least 1 arguments but got 0.",
+		`counts([1]);`:                "Argument 1: element 0 expected string but got a number.",
+		`origin.X = "x";`:             "Can't assign to field 'X': expected float64 but got a string.",
+		"origin.hidden;":              "Undefined property 'hidden'.",
+		"origin.hidden = 1;":          "Undefined field 'hidden'.",
+		"small(128);":                 "Argument 1: expected int8 but got 128.",
+		"big(100000000000000000000);": "Argument 1: expected int64 but got 1e+20.",
+		// 2^63 is one more than the largest int64.
+		"big(9223372036854775808);": "Argument 1: expected int64 but got 9.223372036854776e+18.",
+		"unsigned(4294967296);":     "Argument 1: expected uint32 but got 4.294967296e+09.",
+		"unsigned(-1);":             "Argument 1: expected uint32 but got -1.",
+		"describe({[1]: 2});":       "Argument 1: key [1] can't be the key of a Go map.",
+	} {
+		_, _, err := interpreter.Run(source)
+		if runtimeErr, ok := err.(*lox.RuntimeError); !ok || runtimeErr.Message != message {
+			t.Errorf("%s: expected runtime error %q, got %v", source, message, err)
+		}
+	}
+}
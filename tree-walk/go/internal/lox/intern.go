@@ -0,0 +1,64 @@
+package lox
+
+import "strings"
+
+// symbol is the canonical handle of an interned string. Equal strings
+// interned in the same table have the same symbol, so a symbol can stand for
+// its name as a map key that hashes and compares as a pointer.
+type symbol struct {
+	name string
+	// table is the table that interned the name.
+	table *stringTable
+}
+
+// stringTable interns strings: it keeps one copy of each distinct string and
+// hands it out for every equal string interned after it. The Scanner interns
+// identifiers and string literals, and the Interpreter shares its table with
+// the Scanner for every script and module it compiles. Nothing is removed from
+// the table, so it grows with the distinct names and literals compiled.
+//
+// Global variables and instance fields are kept by symbol, so looking one up
+// with a token from the table hashes a pointer rather than the name. Lox
+// strings are still Go strings: == and map keys compare their contents, and
+// strings built at runtime, by concatenation or natives, are not interned.
+type stringTable struct {
+	symbols map[string]*symbol
+}
+
+func newStringTable() *stringTable {
+	return &stringTable{symbols: make(map[string]*symbol)}
+}
+
+// symbol returns the handle of s, interning it if it is new.
+func (t *stringTable) symbol(s string) *symbol {
+	if sym, ok := t.symbols[s]; ok {
+		return sym
+	}
+
+	// s may be a slice of a whole source file, which interning it should
+	// not keep alive.
+	sym := &symbol{name: strings.Clone(s), table: t}
+	t.symbols[sym.name] = sym
+	return sym
+}
+
+// find returns the handle of s, or nil if s was never interned.
+func (t *stringTable) find(s string) *symbol {
+	return t.symbols[s]
+}
+
+// lookup returns the symbol of name's lexeme. Tokens scanned with t carry
+// theirs; others, including those from a Scanner created by NewScanner, have
+// their lexeme looked up. It returns nil for a name that was never interned,
+// so looking up an undefined name does not grow the table.
+func (t *stringTable) lookup(name *Token) *symbol {
+	if name.symbol != nil && name.symbol.table == t {
+		return name.symbol
+	}
+	return t.find(name.Lexeme)
+}
+
+// intern returns the interned copy of s.
+func (t *stringTable) intern(s string) string {
+	return t.symbol(s).name
+}
@@ -1,48 +1,215 @@
 package lox
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"math/rand"
+	"os"
 	"strings"
 )
 
+// defaultMaxCallDepth is how deeply Lox calls may nest before the interpreter
+// reports a stack overflow. It is well within what the Go stack can hold.
+const defaultMaxCallDepth = 4096
+
 type Interpreter struct {
-	globals     *environment
+	// builtins holds the native functions and classes every module can use.
+	builtins *environment
+	// globals holds the top-level declarations of the running module.
+	globals *environment
+	module  *loxModule
+	// modules caches imported modules by canonical path. A nil entry is a
+	// module that is still running.
+	modules     map[string]*loxModule
 	environment *environment
-	locals      map[Expr]int
+	locals      map[Expr]local
+	stdout      io.Writer
+	frames      []frame
+	limits      Limits
+	budget      budget
+	// returnValue and thrown hold the value of a completionReturn and the
+	// error of a completionThrow until a statement handles them.
+	returnValue any
+	thrown      *RuntimeError
+	// lastCharacters indexes the characters of the last string indexed.
+	lastCharacters characterIndex
+	capabilities   capabilities
+	random         *rand.Rand
+	// strings interns the lexemes and string literals of every script and
+	// module the interpreter compiles.
+	strings *stringTable
+	// errorClass is the built-in Error class that runtime errors are
+	// turned into when caught.
+	errorClass *loxClass
+}
+
+// Option configures an Interpreter created by NewInterpreter.
+type Option func(i *Interpreter)
+
+// WithOutput sends the output of print statements to w instead of os.Stdout.
+func WithOutput(w io.Writer) Option {
+	return func(i *Interpreter) {
+		i.stdout = w
+	}
 }
 
-func NewInterpreter() *Interpreter {
-	g := newEnvironment(nil)
-	g.define("clock", newClockNativeFunction())
-	return &Interpreter{
-		globals:     g,
-		environment: g,
-		locals:      make(map[Expr]int, 0),
+func NewInterpreter(options ...Option) *Interpreter {
+	table := newStringTable()
+	builtins := newGlobalEnvironment(nil, table)
+	i := &Interpreter{
+		builtins:     builtins,
+		modules:      make(map[string]*loxModule),
+		locals:       make(map[Expr]local),
+		stdout:       os.Stdout,
+		capabilities: capabilities{CapClock: nil},
+		strings:      table,
+	}
+	for _, option := range options {
+		option(i)
 	}
+	i.defineCapabilityNatives()
+	i.defineMathNatives()
+	i.defineStringNatives()
+	i.runPrelude()
+
+	main := newLoxModule("", builtins)
+	i.enterModule(main)
+	i.environment = main.globals
+	return i
+}
+
+// Run scans, parses, resolves and executes source. Any compile errors are
+// returned as diagnostics and nothing is executed; a runtime error is returned
+// as a *RuntimeError. The value is that of the final statement when it is an
+// expression statement, and nil otherwise.
+func (i *Interpreter) Run(source string) (Value, []Diagnostic, error) {
+	return i.RunContext(context.Background(), source)
+}
+
+// RunContext is Run, stopping the script with a *LimitError if ctx is done
+// before it finishes.
+func (i *Interpreter) RunContext(ctx context.Context, source string) (Value, []Diagnostic, error) {
+	statements, diagnostics := i.compile(source)
+	if len(diagnostics) > 0 {
+		return nil, diagnostics, nil
+	}
+
+	value, err := i.Interpret(ctx, statements)
+	return value, nil, err
 }
 
-func (i *Interpreter) Interpret(statements []Stmt) {
+// RunFile runs the script at path like Run. Modules it imports are found
+// relative to the script.
+func (i *Interpreter) RunFile(path string) (Value, []Diagnostic, error) {
+	return i.RunFileContext(context.Background(), path)
+}
+
+// RunFileContext is RunFile, stopping the script with a *LimitError if ctx is
+// done before it finishes.
+func (i *Interpreter) RunFileContext(ctx context.Context, path string) (Value, []Diagnostic, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if path, err = canonicalPath(path); err != nil {
+		return nil, nil, err
+	}
+
+	// The script is a module too, so importing it while it runs is a cycle.
+	// It runs in the interpreter's globals rather than a module of its own,
+	// so once it ends, importing it runs it again as a module.
+	previousPath := i.module.path
+	previous, cached := i.modules[path]
+	i.module.path = path
+	i.modules[path] = nil
 	defer func() {
-		if err := recover(); err != nil {
-			if runtimeErr, ok := err.(runtimeError); ok {
-				reportRuntimeError(runtimeErr)
-				return
+		i.module.path = previousPath
+		if cached {
+			i.modules[path] = previous
+		} else {
+			delete(i.modules, path)
+		}
+	}()
+	return i.RunContext(ctx, string(source))
+}
+
+// compile scans, parses and resolves source.
+func (i *Interpreter) compile(source string) ([]Stmt, []Diagnostic) {
+	scanner := newScanner(source, i.strings)
+	tokens := scanner.ScanTokens()
+	parser := NewParser(tokens)
+	statements := parser.Parse()
+
+	// Stop if there was a syntax error.
+	diagnostics := append(scanner.Errors(), parser.Errors()...)
+	if len(diagnostics) > 0 {
+		return nil, diagnostics
+	}
+
+	resolver := NewResolver(i)
+	resolver.Resolve(statements)
+
+	// Stop if there was a resolution error.
+	if diagnostics := resolver.Errors(); len(diagnostics) > 0 {
+		return nil, diagnostics
+	}
+
+	return statements, nil
+}
+
+// Interpret executes statements that have been resolved by this interpreter.
+// It returns a *RuntimeError if the script fails, and a *LimitError if it goes
+// over the interpreter's Limits or ctx is done before it finishes.
+func (i *Interpreter) Interpret(ctx context.Context, statements []Stmt) (value Value, err error) {
+	if i.limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.limits.Timeout)
+		defer cancel()
+	}
+	i.resetBudget(ctx)
+
+	environment, module := i.environment, i.module
+	defer func() {
+		if r := recover(); r != nil {
+			switch r := r.(type) {
+			case *LimitError:
+				err = r
+			case *RuntimeError:
+				i.locate(r)
+				i.captureStackTrace(r)
+				err = r
+			default:
+				panic(r)
 			}
-			panic(err)
+
+			// Nothing restored what the error unwound.
+			i.frames = i.frames[:0]
+			i.environment = environment
+			i.enterModule(module)
+			value = nil
 		}
 	}()
 
 	for _, statement := range statements {
-		i.execute(statement)
+		value = nil
+		if stmt, ok := statement.(*Expression); ok {
+			i.step(stmt.Span())
+			value = i.evaluate(stmt.Expression)
+			continue
+		}
+		if i.execute(statement) == completionThrow {
+			panic(i.thrown)
+		}
 	}
+	return value, nil
 }
 
 func (i *Interpreter) VisitAssignExpr(expr *Assign) any {
 	value := i.evaluate(expr.Value)
 
-	distance, ok := i.locals[expr]
-	if ok {
-		i.environment.assignAt(distance, expr.Name, value)
+	if local, ok := i.locals[expr]; ok {
+		i.environment.assignAt(local.depth, local.slot, value)
 	} else {
 		i.globals.assign(expr.Name, value)
 	}
@@ -93,6 +260,7 @@ func (i *Interpreter) VisitBinaryExpr(expr *Binary) any {
 		ls, lok := left.(string)
 		rs, rok := right.(string)
 		if lok && rok {
+			i.allocate(expr.Operator)
 			return ls + rs
 		}
 
@@ -112,29 +280,83 @@ func (i *Interpreter) VisitBinaryExpr(expr *Binary) any {
 }
 
 func (i *Interpreter) VisitCallExpr(expr *Call) any {
-	callee := i.evaluate(expr.Callee)
+	get, ok := expr.Callee.(*Get)
+	if !ok {
+		return i.call(i.evaluate(expr.Callee), expr)
+	}
 
-	arguments := make([]any, 0)
-	for _, argument := range expr.Arguments {
-		arguments = append(arguments, i.evaluate(argument))
+	// A method called where it is looked up, as in object.method(), is
+	// invoked on the instance without binding it first.
+	object := i.evaluate(get.Object)
+	if instance, ok := object.(*loxInstance); ok {
+		if _, shadowed := instance.field(get.Name); !shadowed {
+			if method := get.method(instance.class); method != nil {
+				arguments := i.evaluateArguments(expr.Arguments)
+				i.enterCall(method, arguments, expr.Paren)
+				result := method.invoke(i, instance, arguments)
+				i.frames = i.frames[:len(i.frames)-1]
+				return result
+			}
+		}
 	}
+	return i.call(i.property(object, get), expr)
+}
+
+// call evaluates the arguments of expr and calls callee with them.
+func (i *Interpreter) call(callee any, expr *Call) any {
+	arguments := i.evaluateArguments(expr.Arguments)
 
 	function, ok := callee.(LoxCallable)
 	if !ok {
 		panic(newRuntimeError(expr.Paren, "Can only call functions and classes."))
 	}
 
-	if len(arguments) != function.arity() {
-		panic(newRuntimeError(expr.Paren, fmt.Sprintf("Expected %d arguments but got %d.", function.arity(), len(arguments))))
+	i.enterCall(function, arguments, expr.Paren)
+	result := function.call(i, arguments)
+	i.frames = i.frames[:len(i.frames)-1]
+	return result
+}
+
+func (i *Interpreter) evaluateArguments(expressions []Expr) []any {
+	arguments := make([]any, len(expressions))
+	for j, argument := range expressions {
+		arguments[j] = i.evaluate(argument)
+	}
+	return arguments
+}
+
+// enterCall checks that function can be called with arguments at paren and
+// pushes its frame. The caller pops the frame once the call returns; it is
+// left in place if the call panics with a runtime error so Interpret can
+// report the stack as it was.
+func (i *Interpreter) enterCall(function LoxCallable, arguments []any, paren *Token) {
+	if arity := function.arity(); arity != variadic && len(arguments) != arity {
+		panic(newRuntimeError(paren, fmt.Sprintf("Expected %d arguments but got %d.", arity, len(arguments))))
 	}
 
-	return function.call(i, arguments)
+	if max := i.limits.MaxCallDepth; max > 0 && len(i.frames) >= max {
+		panic(newLimitError(paren.Span(), fmt.Sprintf("Call depth limit of %d exceeded.", max)))
+	}
+	if len(i.frames) >= defaultMaxCallDepth {
+		panic(newRuntimeError(paren, "Stack overflow."))
+	}
+
+	i.frames = append(i.frames, newFrame(function, paren))
 }
 
 func (i *Interpreter) VisitGetExpr(expr *Get) any {
-	object := i.evaluate(expr.Object)
-	if instance, ok := object.(*loxInstance); ok {
-		return instance.get(expr.Name)
+	return i.property(i.evaluate(expr.Object), expr)
+}
+
+// property reads the property expr names from object.
+func (i *Interpreter) property(object any, expr *Get) any {
+	switch object := object.(type) {
+	case *loxInstance:
+		return object.getProperty(expr)
+	case propertyGetter:
+		return object.get(expr.Name)
+	case string:
+		return i.stringProperty(object, expr.Name)
 	}
 
 	panic(newRuntimeError(expr.Name, "Only instances have properties."))
@@ -144,6 +366,37 @@ func (i *Interpreter) VisitGroupingExpr(expr *Grouping) any {
 	return i.evaluate(expr.Expression)
 }
 
+func (i *Interpreter) VisitIndexExpr(expr *Index) any {
+	object := i.evaluate(expr.Object)
+	index := i.evaluate(expr.Index)
+
+	switch object := object.(type) {
+	case *loxList:
+		return object.getAt(expr.Bracket, index)
+	case *loxMap:
+		return object.getAt(expr.Bracket, index)
+	case string:
+		character := i.stringAt(object, expr.Bracket, index)
+		i.allocate(expr.Bracket)
+		return character
+	}
+
+	panic(newRuntimeError(expr.Bracket, "Only lists, maps and strings can be indexed."))
+}
+
+func (i *Interpreter) VisitLambdaExpr(expr *Lambda) any {
+	return newLoxFunction(expr.Function, i.environment, i.module, false)
+}
+
+func (i *Interpreter) VisitListExpr(expr *List) any {
+	elements := make([]any, 0, len(expr.Elements))
+	for _, element := range expr.Elements {
+		elements = append(elements, i.evaluate(element))
+	}
+	i.allocate(expr.Bracket)
+	return newLoxList(elements)
+}
+
 func (i *Interpreter) VisitLiteralExpr(expr *Literal) any {
 	return expr.Value
 }
@@ -164,9 +417,18 @@ func (i *Interpreter) VisitLogicalExpr(expr *Logical) any {
 	return i.evaluate(expr.Right)
 }
 
+func (i *Interpreter) VisitMapExpr(expr *Map) any {
+	i.allocate(expr.Brace)
+	m := newLoxMap()
+	for j, key := range expr.Keys {
+		m.setAt(expr.Brace, i.evaluate(key), i.evaluate(expr.Values[j]))
+	}
+	return m
+}
+
 func (i *Interpreter) VisitSetExpr(expr *Set) any {
 	object := i.evaluate(expr.Object)
-	instance, ok := object.(*loxInstance)
+	instance, ok := object.(propertySetter)
 	if !ok {
 		panic(newRuntimeError(expr.Name, "Only instances have fields."))
 	}
@@ -176,11 +438,32 @@ func (i *Interpreter) VisitSetExpr(expr *Set) any {
 	return value
 }
 
+func (i *Interpreter) VisitSetIndexExpr(expr *SetIndex) any {
+	object := i.evaluate(expr.Object)
+	index := i.evaluate(expr.Index)
+	value := i.evaluate(expr.Value)
+
+	switch object := object.(type) {
+	case *loxList:
+		object.setAt(expr.Bracket, index, value)
+		return value
+	case *loxMap:
+		object.setAt(expr.Bracket, index, value)
+		return value
+	case string:
+		panic(newRuntimeError(expr.Bracket, "Strings can't be changed."))
+	}
+
+	panic(newRuntimeError(expr.Bracket, "Only lists, maps and strings can be indexed."))
+}
+
 func (i *Interpreter) VisitSuperExpr(expr *Super) any {
-	distance := i.locals[expr]
-	superclass, _ := i.environment.getAt(distance, "super").(*loxClass)
+	// "super" is alone in its scope, which encloses the scope of the method,
+	// where "this" is first.
+	distance := i.locals[expr].depth
+	superclass, _ := i.environment.getAt(distance, 0).(*loxClass)
 
-	instance, _ := i.environment.getAt(distance-1, "this").(*loxInstance)
+	instance, _ := i.environment.getAt(distance-1, 0).(*loxInstance)
 
 	method := superclass.findMethod(expr.Method.Lexeme)
 	if method == nil {
@@ -214,8 +497,11 @@ func (i *Interpreter) VisitVariableExpr(expr *Variable) any {
 }
 
 func (i *Interpreter) VisitBlockStmt(stmt *Block) any {
-	i.executeBlock(stmt.Statements, newEnvironment(i.environment))
-	return nil
+	return i.executeBlock(stmt.Statements, newEnvironment(i.environment, 0))
+}
+
+func (i *Interpreter) VisitBreakStmt(stmt *Break) any {
+	return completionBreak
 }
 
 func (i *Interpreter) VisitClassStmt(stmt *Class) any {
@@ -228,16 +514,15 @@ func (i *Interpreter) VisitClassStmt(stmt *Class) any {
 		}
 		superclass = c
 	}
-	i.environment.define(stmt.Name.Lexeme, nil)
-
 	if superclass != nil {
-		i.environment = newEnvironment(i.environment)
+		i.environment = newEnvironment(i.environment, 1)
 		i.environment.define("super", superclass)
 	}
 
 	methods := map[string]*loxFunction{}
 	for _, method := range stmt.Methods {
-		function := newLoxFunction(method, i.environment, method.Name.Lexeme == "init")
+		function := newLoxFunction(method, i.environment, i.module, method.Name.Lexeme == "init")
+		function.class = stmt.Name.Lexeme
 		methods[method.Name.Lexeme] = function
 	}
 
@@ -247,34 +532,47 @@ func (i *Interpreter) VisitClassStmt(stmt *Class) any {
 		i.environment = i.environment.enclosing
 	}
 
-	i.environment.assign(stmt.Name, class)
+	// Methods refer to the class through their closures, so it can be
+	// defined once they have been created.
+	i.environment.define(stmt.Name.Lexeme, class)
 
-	return nil
+	return completionNormal
+}
+
+func (i *Interpreter) VisitContinueStmt(stmt *Continue) any {
+	return completionContinue
 }
 
 func (i *Interpreter) VisitExpressionStmt(stmt *Expression) any {
-	return i.evaluate(stmt.Expression)
+	i.evaluate(stmt.Expression)
+	return completionNormal
 }
 
 func (i *Interpreter) VisitFunctionStmt(stmt *Function) any {
-	function := newLoxFunction(stmt, i.environment, false)
+	function := newLoxFunction(stmt, i.environment, i.module, false)
 	i.environment.define(stmt.Name.Lexeme, function)
-	return nil
+	return completionNormal
 }
 
 func (i *Interpreter) VisitIfStmt(stmt *If) any {
 	if i.isTruthy(i.evaluate(stmt.Condition)) {
-		i.execute(stmt.ThenBranch)
+		return i.execute(stmt.ThenBranch)
 	} else if stmt.ElseBranch != nil {
-		i.execute(stmt.ElseBranch)
+		return i.execute(stmt.ElseBranch)
 	}
-	return nil
+	return completionNormal
+}
+
+func (i *Interpreter) VisitImportStmt(stmt *Import) any {
+	module := i.importModule(stmt.Path)
+	i.environment.define(stmt.Name.Lexeme, module)
+	return completionNormal
 }
 
 func (i *Interpreter) VisitPrintStmt(stmt *Print) any {
 	value := i.evaluate(stmt.Expression)
-	fmt.Println(stringify(value))
-	return nil
+	fmt.Fprintln(i.stdout, Stringify(value))
+	return completionNormal
 }
 
 func (i *Interpreter) VisitReturnStmt(stmt *Return) any {
@@ -283,7 +581,126 @@ func (i *Interpreter) VisitReturnStmt(stmt *Return) any {
 		value = i.evaluate(stmt.Value)
 	}
 
-	panic(newReturnControl(value))
+	i.returnValue = value
+	return completionReturn
+}
+
+func (i *Interpreter) VisitThrowStmt(stmt *Throw) any {
+	value := i.evaluate(stmt.Value)
+
+	message := Stringify(value)
+	if instance, ok := i.errorInstance(value); ok {
+		if _, ok := instance.fieldNamed("line"); !ok {
+			instance.setFieldNamed("line", float64(stmt.Keyword.Line))
+		}
+		m, _ := instance.fieldNamed("message")
+		if m, ok := m.(string); ok {
+			message = m
+		}
+	}
+
+	err := newRuntimeError(stmt.Keyword, message)
+	err.Value = value
+	err.thrown = true
+	i.locate(err)
+	i.captureStackTrace(err)
+	i.thrown = err
+	return completionThrow
+}
+
+func (i *Interpreter) VisitTryStmt(stmt *Try) any {
+	completion, err := i.tryBlock(stmt.Body, newEnvironment(i.environment, 0))
+
+	if err != nil && stmt.CatchName != nil {
+		environment := newEnvironment(i.environment, 1)
+		environment.define(stmt.CatchName.Lexeme, i.caughtValue(err))
+		if stmt.FinallyBody == nil {
+			return i.executeBlock(stmt.CatchBody, environment)
+		}
+		completion, err = i.tryBlock(stmt.CatchBody, environment)
+	}
+
+	if stmt.FinallyBody != nil {
+		// The finally block runs however the rest ended, and then that
+		// carries on unless the finally block itself ends early. Calls in
+		// the finally block may overwrite a value being returned.
+		returnValue := i.returnValue
+		if completion := i.executeBlock(stmt.FinallyBody, newEnvironment(i.environment, 0)); completion != completionNormal {
+			return completion
+		}
+		i.returnValue = returnValue
+	}
+
+	if err != nil {
+		i.thrown = err
+		return completionThrow
+	}
+	return completion
+}
+
+// tryBlock runs body in environment and returns the runtime error that
+// escapes it, if any, whether thrown or raised by the interpreter.
+func (i *Interpreter) tryBlock(body []Stmt, environment *environment) (c completion, err *RuntimeError) {
+	enclosing, module, depth := i.environment, i.module, len(i.frames)
+	defer func() {
+		if r := recover(); r != nil {
+			runtimeErr, ok := r.(*RuntimeError)
+			if !ok {
+				panic(r)
+			}
+
+			i.locate(runtimeErr)
+			i.captureStackTrace(runtimeErr)
+			// Restore what the error unwound.
+			i.frames = i.frames[:depth]
+			i.environment = enclosing
+			i.enterModule(module)
+			err = runtimeErr
+		}
+	}()
+
+	c = i.executeBlock(body, environment)
+	if c == completionThrow {
+		return completionNormal, i.thrown
+	}
+	return c, nil
+}
+
+// caughtValue is the value a catch clause binds for err: whatever was thrown,
+// or an Error instance describing an error raised by the interpreter.
+func (i *Interpreter) caughtValue(err *RuntimeError) any {
+	if err.thrown {
+		return err.Value
+	}
+
+	instance := newLoxInstance(i.errorClass, i.strings)
+	instance.setFieldNamed("message", err.Message)
+	instance.setFieldNamed("line", float64(err.Token.Line))
+	return instance
+}
+
+// errorInstance reports whether value is an instance of Error or one of its
+// subclasses.
+func (i *Interpreter) errorInstance(value any) (*loxInstance, bool) {
+	instance, ok := value.(*loxInstance)
+	if !ok {
+		return nil, false
+	}
+
+	for class := instance.class; class != nil; class = class.superclass {
+		if class == i.errorClass {
+			return instance, true
+		}
+	}
+	return nil, false
+}
+
+// captureStackTrace records the calls active when err was raised, unless an
+// enclosing finally block already did so.
+func (i *Interpreter) captureStackTrace(err *RuntimeError) {
+	if err.StackTrace == nil {
+		err.StackTrace = stackTrace(i.frames, err.Token.Line)
+	}
 }
 
 func (i *Interpreter) VisitVarStmt(stmt *Var) any {
@@ -293,36 +710,55 @@ func (i *Interpreter) VisitVarStmt(stmt *Var) any {
 	}
 
 	i.environment.define(stmt.Name.Lexeme, value)
-	return nil
+	return completionNormal
 }
 
 func (i *Interpreter) VisitWhileStmt(stmt *While) any {
 	for i.isTruthy(i.evaluate(stmt.Condition)) {
-		i.execute(stmt.Body)
+		switch completion := i.execute(stmt.Body); completion {
+		case completionBreak:
+			return completionNormal
+		case completionReturn, completionThrow:
+			return completion
+		}
+		if stmt.Increment != nil {
+			i.evaluate(stmt.Increment)
+		}
 	}
-	return nil
+	return completionNormal
 }
 
 func (i *Interpreter) evaluate(expr Expr) any {
 	return expr.Accept(i)
 }
 
-func (i *Interpreter) execute(stmt Stmt) {
-	stmt.Accept(i)
+func (i *Interpreter) execute(stmt Stmt) completion {
+	i.step(stmt.Span())
+	return stmt.Accept(i).(completion)
 }
 
-func (i *Interpreter) executeBlock(statements []Stmt, environment *environment) {
-	previousEnvironment := i.environment
-
-	defer func() {
-		i.environment = previousEnvironment
-	}()
+// callSite returns the closing parenthesis of the innermost call, for errors
+// raised by native functions.
+func (i *Interpreter) callSite() *Token {
+	return i.frames[len(i.frames)-1].site
+}
 
+// executeBlock runs statements in environment until one ends early. A
+// runtime error leaves environment in place for whatever recovers from it to
+// restore.
+func (i *Interpreter) executeBlock(statements []Stmt, environment *environment) completion {
+	previousEnvironment := i.environment
 	i.environment = environment
 
 	for _, statement := range statements {
-		i.execute(statement)
+		if completion := i.execute(statement); completion != completionNormal {
+			i.environment = previousEnvironment
+			return completion
+		}
 	}
+
+	i.environment = previousEnvironment
+	return completionNormal
 }
 
 func (i *Interpreter) isTruthy(object any) bool {
@@ -345,20 +781,37 @@ func (i *Interpreter) isEqual(a, b any) bool {
 		return false
 	}
 
+	// Comparing strings directly skips the dispatch on their dynamic type
+	// that comparing interfaces does.
+	if a, ok := a.(string); ok {
+		b, ok := b.(string)
+		return ok && a == b
+	}
+
 	return a == b
 }
 
-func (i *Interpreter) resolve(expr Expr, depth int) {
-	i.locals[expr] = depth
+// local is where the Resolver found a local variable: how many scopes out
+// from the one it is used in, and its slot in that scope.
+type local struct {
+	depth int
+	slot  int
+}
+
+func (i *Interpreter) resolve(expr Expr, depth, slot int) {
+	i.locals[expr] = local{depth: depth, slot: slot}
 }
 
 func (i *Interpreter) lookupVariable(name *Token, expr Expr) any {
-	distance, ok := i.locals[expr]
-	if ok {
-		return i.environment.getAt(distance, name.Lexeme)
-	} else {
-		return i.globals.get(name)
+	if local, ok := i.locals[expr]; ok {
+		return i.environment.getAt(local.depth, local.slot)
 	}
+
+	value, ok := i.globals.lookup(name)
+	if !ok {
+		i.undefinedVariable(name)
+	}
+	return value
 }
 
 func checkNumberOperand(token *Token, operand any) {
@@ -378,7 +831,8 @@ func checkNumberOperands(token *Token, left, right any) {
 	panic(newRuntimeError(token, "Operands must be numbers."))
 }
 
-func stringify(object any) string {
+// Stringify formats a Lox value the way print shows it.
+func Stringify(object any) string {
 	if object == nil {
 		return "nil"
 	}
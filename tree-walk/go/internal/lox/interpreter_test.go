@@ -0,0 +1,31 @@
+package lox_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+// TestPipeline runs a script through the exported stages one at a time, as
+// an embedder that inspects the syntax tree would.
+func TestPipeline(t *testing.T) {
+	var stdout bytes.Buffer
+	interpreter := lox.NewInterpreter(lox.WithOutput(&stdout))
+
+	source := "var a = 1;\na = a + 1;\nprint a;"
+	statements := lox.NewParser(lox.NewScanner(source).ScanTokens()).Parse()
+	resolver := lox.NewResolver(interpreter)
+	resolver.Resolve(statements)
+	if diagnostics := resolver.Errors(); len(diagnostics) > 0 {
+		t.Fatal(diagnostics)
+	}
+
+	if _, err := interpreter.Interpret(context.Background(), statements); err != nil {
+		t.Fatal(err)
+	}
+	if got := stdout.String(); got != "2\n" {
+		t.Errorf("expected output %q, got %q", "2\n", got)
+	}
+}
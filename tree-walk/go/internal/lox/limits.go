@@ -0,0 +1,125 @@
+package lox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// checkInterval is how many statements run between checks of the context.
+const checkInterval = 1024
+
+// Limits bounds the work a script may do. A zero field means no limit. A
+// script that goes over a limit is stopped with a *LimitError.
+type Limits struct {
+	// MaxStatements is how many statements a script may execute.
+	MaxStatements int
+	// MaxCallDepth is how deeply calls may nest. Without it, calls nested
+	// 4096 deep fail with a "Stack overflow." runtime error, which Lox code
+	// can catch like any other.
+	MaxCallDepth int
+	// MaxAllocations is how many instances, lists, maps and strings a
+	// script may create, whether by literals, concatenation, indexing a
+	// string or calling natives.
+	MaxAllocations int
+	// Timeout is how long a script may run.
+	Timeout time.Duration
+}
+
+// WithLimits bounds each script the interpreter runs.
+func WithLimits(limits Limits) Option {
+	return func(i *Interpreter) {
+		i.limits = limits
+	}
+}
+
+// LimitError stops a script that went over one of its Limits or whose
+// context was done. Unlike a RuntimeError, Lox code can't catch it.
+type LimitError struct {
+	Message string
+	// Err is the context's error if the context stopped the script.
+	Err  error
+	span Span
+}
+
+func newLimitError(span Span, message string) *LimitError {
+	return &LimitError{
+		Message: message,
+		span:    span,
+	}
+}
+
+func (e *LimitError) Span() Span {
+	return e.span
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s\n[line %d]", e.Message, e.span.Line)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// budget is what a script run by Interpret has used of its Limits.
+type budget struct {
+	ctx         context.Context
+	statements  int
+	allocations int
+	// nextCheck is the statement count at which to next check the limit
+	// on statements and the context.
+	nextCheck int
+}
+
+func (i *Interpreter) resetBudget(ctx context.Context) {
+	i.budget = budget{ctx: ctx}
+}
+
+// step counts a statement about to execute at span.
+func (i *Interpreter) step(span Span) {
+	i.budget.statements++
+	if i.budget.statements < i.budget.nextCheck {
+		return
+	}
+
+	if max := i.limits.MaxStatements; max > 0 && i.budget.statements > max {
+		panic(newLimitError(span, fmt.Sprintf("Statement limit of %d exceeded.", max)))
+	}
+	if err := i.budget.ctx.Err(); err != nil {
+		message := "Execution cancelled."
+		if errors.Is(err, context.DeadlineExceeded) {
+			message = "Execution timed out."
+		}
+		limitErr := newLimitError(span, message)
+		limitErr.Err = err
+		panic(limitErr)
+	}
+
+	i.budget.nextCheck = i.budget.statements + checkInterval
+	if max := i.limits.MaxStatements; max > 0 && i.budget.nextCheck > max+1 {
+		i.budget.nextCheck = max + 1
+	}
+}
+
+// allocate counts a value created at token.
+func (i *Interpreter) allocate(token *Token) {
+	max := i.limits.MaxAllocations
+	if max == 0 {
+		return
+	}
+
+	i.budget.allocations++
+	if i.budget.allocations > max {
+		panic(newLimitError(token.Span(), fmt.Sprintf("Allocation limit of %d exceeded.", max)))
+	}
+}
+
+// allocateResult counts value, returned by a native called at token, if it is
+// a string, list or map, which a native creates rather than finds.
+func (i *Interpreter) allocateResult(token *Token, value any) {
+	switch value.(type) {
+	case string, *loxList, *loxMap:
+		i.allocate(token)
+	}
+}
@@ -0,0 +1,142 @@
+package lox_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  lox.Limits
+		source  string
+		message string
+		line    int
+	}{
+		{
+			name:    "statements",
+			limits:  lox.Limits{MaxStatements: 101},
+			source:  "var i = 0;\nwhile (true) {\n  i = i + 1;\n}",
+			message: "Statement limit of 101 exceeded.",
+			line:    3,
+		},
+		{
+			name:    "allocations",
+			limits:  lox.Limits{MaxAllocations: 3},
+			source:  "class A {}\nvar s = \"a\" + \"b\";\nvar l = [A()];\nvar m = {};",
+			message: "Allocation limit of 3 exceeded.",
+			line:    4,
+		},
+		{
+			name:    "top-level expressions",
+			limits:  lox.Limits{MaxStatements: 2},
+			source:  "1;\n2;\n3;",
+			message: "Statement limit of 2 exceeded.",
+			line:    3,
+		},
+		{
+			name:    "native allocations",
+			limits:  lox.Limits{MaxAllocations: 3},
+			source:  "var s = \"abc\";\ns.upper();\ns[0];\nstr(1);\ns.substring(0, 1);",
+			message: "Allocation limit of 3 exceeded.",
+			line:    5,
+		},
+		{
+			name:    "timeout",
+			limits:  lox.Limits{Timeout: 10 * time.Millisecond},
+			source:  "while (true) {}",
+			message: "Execution timed out.",
+			line:    1,
+		},
+		{
+			// Neither catch nor finally runs when a script is stopped.
+			name:    "uncatchable",
+			limits:  lox.Limits{MaxStatements: 10},
+			source:  "try {\n  while (true) {}\n} catch (e) {\n  print \"caught\";\n} finally {\n  print \"finally\";\n}",
+			message: "Statement limit of 10 exceeded.",
+			line:    2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			interpreter := lox.NewInterpreter(lox.WithOutput(&stdout), lox.WithLimits(test.limits))
+			_, _, err := interpreter.Run(test.source)
+
+			var limitErr *lox.LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("expected a limit error, got %v", err)
+			}
+			if limitErr.Message != test.message || limitErr.Span().Line != test.line {
+				t.Errorf("expected %q on line %d, got %q on line %d", test.message, test.line, limitErr.Message, limitErr.Span().Line)
+			}
+			if stdout.Len() > 0 {
+				t.Errorf("expected no output, got %q", stdout.String())
+			}
+
+			// The budget is renewed for each script.
+			if _, _, err := interpreter.Run("print 1;"); err != nil {
+				t.Errorf("expected the next script to run, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	_, _, err := lox.NewInterpreter().RunContext(ctx, "while (true) {}")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the script to be cancelled, got %v", err)
+	}
+	if err.Error() != "Execution cancelled.\n[line 1]" {
+		t.Errorf("expected cancellation message, got %q", err)
+	}
+}
+
+// TestCallDepth checks that a script can't catch going over MaxCallDepth,
+// though it can catch a stack overflow.
+func TestCallDepth(t *testing.T) {
+	source := `fun f(n) {
+  if (n == 0) return 0;
+  return f(n - 1) + 1;
+}
+print f(9);
+try {
+  f(10);
+} catch (e) {
+  print "caught";
+}`
+
+	var stdout bytes.Buffer
+	interpreter := lox.NewInterpreter(lox.WithOutput(&stdout), lox.WithLimits(lox.Limits{MaxCallDepth: 10}))
+	_, _, err := interpreter.Run(source)
+	if want := "9\n"; stdout.String() != want {
+		t.Errorf("expected output %q, got %q", want, stdout.String())
+	}
+	var limitErr *lox.LimitError
+	if !errors.As(err, &limitErr) || limitErr.Message != "Call depth limit of 10 exceeded." || limitErr.Span().Line != 3 {
+		t.Errorf("expected the call depth limit on line 3, got %v", err)
+	}
+
+	stdout.Reset()
+	_, _, err = lox.NewInterpreter(lox.WithOutput(&stdout)).Run(`fun f() { f(); }
+try {
+  f();
+} catch (e) {
+  print e.message;
+}`)
+	if err != nil || stdout.String() != "Stack overflow.\n" {
+		t.Errorf("expected the stack overflow to be caught, got %q and %v", stdout.String(), err)
+	}
+}
@@ -3,10 +3,25 @@ package lox
 type loxClass struct {
 	name       string
 	superclass *loxClass
-	methods    map[string]*loxFunction
+	// methods holds the methods the class declares and those it inherits,
+	// so finding one takes a single lookup.
+	methods map[string]*loxFunction
 }
 
+// newLoxClass creates a class declaring methods, which override those of the
+// same name in superclass.
 func newLoxClass(name string, superclass *loxClass, methods map[string]*loxFunction) *loxClass {
+	if superclass != nil {
+		flattened := make(map[string]*loxFunction, len(superclass.methods)+len(methods))
+		for name, method := range superclass.methods {
+			flattened[name] = method
+		}
+		for name, method := range methods {
+			flattened[name] = method
+		}
+		methods = flattened
+	}
+
 	return &loxClass{
 		name:       name,
 		superclass: superclass,
@@ -24,28 +39,37 @@ func (c *loxClass) arity() int {
 }
 
 func (c *loxClass) call(interpreter *Interpreter, arguments []any) (returnVal any) {
-	instance := newLoxInstance(c)
+	interpreter.allocate(interpreter.callSite())
+	instance := newLoxInstance(c, interpreter.strings)
 	initializer := c.findMethod("init")
 	if initializer != nil {
-		initializer.bind(instance).call(interpreter, arguments)
+		initializer.invoke(interpreter, instance, arguments)
 	}
 
 	return instance
 }
 
 func (c *loxClass) findMethod(name string) *loxFunction {
-	method, ok := c.methods[name]
-	if ok {
-		return method
-	}
-
-	if c.superclass != nil {
-		return c.superclass.findMethod(name)
-	}
-
-	return nil
+	return c.methods[name]
 }
 
 func (c *loxClass) String() string {
 	return c.name
 }
+
+// methodCache is the inline cache of a Get expression. It remembers the
+// method the expression last found and the class it looked in, since the
+// same expression usually reads from instances of the same class.
+type methodCache struct {
+	class  *loxClass
+	method *loxFunction
+}
+
+// method finds the method expr names in class, or returns nil if there is
+// none.
+func (expr *Get) method(class *loxClass) *loxFunction {
+	if expr.cache.class != class {
+		expr.cache = methodCache{class: class, method: class.findMethod(expr.Name.Lexeme)}
+	}
+	return expr.cache.method
+}
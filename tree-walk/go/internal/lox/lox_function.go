@@ -7,13 +7,20 @@ import (
 type loxFunction struct {
 	declaration *Function
 	closure     *environment
+	module      *loxModule
 	initializer bool
+	// class is the name of the class declaring a method, and empty for
+	// functions.
+	class string
+	// this is the instance a method was bound to, if it was.
+	this *loxInstance
 }
 
-func newLoxFunction(declaration *Function, closure *environment, initializer bool) *loxFunction {
+func newLoxFunction(declaration *Function, closure *environment, module *loxModule, initializer bool) *loxFunction {
 	return &loxFunction{
 		declaration: declaration,
 		closure:     closure,
+		module:      module,
 		initializer: initializer,
 	}
 }
@@ -22,41 +29,64 @@ func (f *loxFunction) arity() int {
 	return len(f.declaration.Parameters)
 }
 
-func (f *loxFunction) call(interpreter *Interpreter, arguments []any) (returnVal any) {
-	defer func() {
-		if r := recover(); r != nil {
-			if returnValue, ok := r.(returnControl); ok {
-				if f.initializer {
-					returnVal = f.closure.getAt(0, "this")
-					return
-				}
-
-				returnVal = returnValue.value
-				return
-			}
+func (f *loxFunction) call(interpreter *Interpreter, arguments []any) any {
+	return f.invoke(interpreter, f.this, arguments)
+}
 
-			panic(r)
-		}
-	}()
+// invoke calls the function. For a method, this is the instance it is called
+// on, which the Resolver expects to find in the first slot.
+func (f *loxFunction) invoke(interpreter *Interpreter, this *loxInstance, arguments []any) any {
+	// Global variables are those of the module the function was declared in.
+	previous := interpreter.enterModule(f.module)
 
-	environment := newEnvironment(f.closure)
+	method := f.class != ""
+	size := len(f.declaration.Parameters)
+	if method {
+		size++
+	}
+	environment := newEnvironment(f.closure, size)
+	if method {
+		environment.define("this", this)
+	}
 	for i, paramter := range f.declaration.Parameters {
 		environment.define(paramter.Lexeme, arguments[i])
 	}
 
-	interpreter.executeBlock(f.declaration.Body, environment)
-	if f.initializer {
-		returnVal = f.closure.getAt(0, "this")
+	completion := interpreter.executeBlock(f.declaration.Body, environment)
+	interpreter.exitModule(previous)
+
+	switch {
+	case completion == completionThrow:
+		// A call is an expression, so the error can only go on unwinding
+		// as a panic.
+		panic(interpreter.thrown)
+	case f.initializer:
+		return this
+	case completion == completionReturn:
+		value := interpreter.returnValue
+		interpreter.returnValue = nil
+		return value
 	}
-	return
+	return nil
 }
 
+// bind returns the method bound to instance, for when it is used as a value
+// rather than called straight away.
 func (f *loxFunction) bind(instance *loxInstance) *loxFunction {
-	environment := newEnvironment(f.closure)
-	environment.define("this", instance)
-	return newLoxFunction(f.declaration, environment, f.initializer)
+	method := *f
+	method.this = instance
+	return &method
+}
+
+// name is the declared name of the function, or "anonymous" for a function
+// expression.
+func (f *loxFunction) name() string {
+	if f.declaration.Name == nil {
+		return "anonymous"
+	}
+	return f.declaration.Name.Lexeme
 }
 
 func (f *loxFunction) String() string {
-	return fmt.Sprintf("<fn %s>", f.declaration.Name.Lexeme)
+	return fmt.Sprintf("<fn %s>", f.name())
 }
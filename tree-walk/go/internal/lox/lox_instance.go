@@ -4,20 +4,51 @@ import (
 	"fmt"
 )
 
+// propertyGetter is a value whose properties can be read with a "." expression.
+type propertyGetter interface {
+	get(name *Token) any
+}
+
+// propertySetter is a value whose properties can be assigned with a "."
+// expression.
+type propertySetter interface {
+	set(name *Token, value any)
+}
+
 type loxInstance struct {
-	class  *loxClass
-	fields map[string]any
+	class *loxClass
+	// fields holds the fields by the symbols of their names, which strings
+	// interns.
+	fields  map[*symbol]any
+	strings *stringTable
 }
 
-func newLoxInstance(class *loxClass) *loxInstance {
+func newLoxInstance(class *loxClass, table *stringTable) *loxInstance {
 	return &loxInstance{
-		class:  class,
-		fields: make(map[string]any),
+		class:   class,
+		fields:  make(map[*symbol]any),
+		strings: table,
 	}
 }
 
+// field returns the value of the field called name, and whether there is one.
+func (i *loxInstance) field(name *Token) (any, bool) {
+	value, ok := i.fields[i.strings.lookup(name)]
+	return value, ok
+}
+
+// fieldNamed is field for a name the interpreter gives rather than a token.
+func (i *loxInstance) fieldNamed(name string) (any, bool) {
+	value, ok := i.fields[i.strings.find(name)]
+	return value, ok
+}
+
+func (i *loxInstance) setFieldNamed(name string, value any) {
+	i.fields[i.strings.symbol(name)] = value
+}
+
 func (i *loxInstance) get(name *Token) any {
-	if value, ok := i.fields[name.Lexeme]; ok {
+	if value, ok := i.field(name); ok {
 		return value
 	}
 
@@ -29,8 +60,25 @@ func (i *loxInstance) get(name *Token) any {
 	panic(newRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme)))
 }
 
+// getProperty is get for a Get expression, whose inline cache finds methods.
+func (i *loxInstance) getProperty(expr *Get) any {
+	if value, ok := i.field(expr.Name); ok {
+		return value
+	}
+
+	if method := expr.method(i.class); method != nil {
+		return method.bind(i)
+	}
+
+	panic(newRuntimeError(expr.Name, fmt.Sprintf("Undefined property '%s'.", expr.Name.Lexeme)))
+}
+
 func (i *loxInstance) set(name *Token, value any) {
-	i.fields[name.Lexeme] = value
+	key := i.strings.lookup(name)
+	if key == nil {
+		key = i.strings.symbol(name.Lexeme)
+	}
+	i.fields[key] = value
 }
 
 func (i *loxInstance) String() string {
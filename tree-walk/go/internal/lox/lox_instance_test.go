@@ -0,0 +1,53 @@
+package lox_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+const fieldsSource = `class Point {
+  init(x) { this.x = x; }
+  sum() { return this.x + this.y; }
+}
+var p = Point(1);
+p.y = 2;
+print p.sum();
+try {
+  throw Error("boom");
+} catch (e) {
+  print e.message;
+  print e.line;
+}`
+
+// TestFields checks fields whether the script is scanned with the
+// interpreter's string table or with one of its own.
+func TestFields(t *testing.T) {
+	want := "3\nboom\n9\n"
+
+	var stdout bytes.Buffer
+	interpreter := lox.NewInterpreter(lox.WithOutput(&stdout))
+	if _, _, err := interpreter.Run(fieldsSource); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != want {
+		t.Errorf("Run: expected output %q, got %q", want, stdout.String())
+	}
+
+	stdout.Reset()
+	interpreter = lox.NewInterpreter(lox.WithOutput(&stdout))
+	statements := lox.NewParser(lox.NewScanner(fieldsSource).ScanTokens()).Parse()
+	resolver := lox.NewResolver(interpreter)
+	resolver.Resolve(statements)
+	if diagnostics := resolver.Errors(); len(diagnostics) > 0 {
+		t.Fatal(diagnostics)
+	}
+	if _, err := interpreter.Interpret(context.Background(), statements); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != want {
+		t.Errorf("Interpret: expected output %q, got %q", want, stdout.String())
+	}
+}
@@ -0,0 +1,130 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type loxList struct {
+	elements []any
+}
+
+func newLoxList(elements []any) *loxList {
+	return &loxList{
+		elements: elements,
+	}
+}
+
+func (l *loxList) get(name *Token) any {
+	switch name.Lexeme {
+	case "length":
+		return float64(len(l.elements))
+	case "push":
+		return newNativeFunction("push", 1, func(interpreter *Interpreter, arguments []any) any {
+			l.elements = append(l.elements, arguments[0])
+			return nil
+		})
+	case "pop":
+		return newNativeFunction("pop", 0, func(interpreter *Interpreter, arguments []any) any {
+			if len(l.elements) == 0 {
+				panic(newRuntimeError(interpreter.callSite(), "Can't pop from an empty list."))
+			}
+			last := l.elements[len(l.elements)-1]
+			l.elements = l.elements[:len(l.elements)-1]
+			return last
+		})
+	case "insert":
+		return newNativeFunction("insert", 2, func(interpreter *Interpreter, arguments []any) any {
+			// Inserting at the length appends.
+			index := l.index(interpreter.callSite(), arguments[0], len(l.elements)+1)
+			l.elements = append(l.elements, nil)
+			copy(l.elements[index+1:], l.elements[index:])
+			l.elements[index] = arguments[1]
+			return nil
+		})
+	case "slice":
+		return newNativeFunction("slice", 2, func(interpreter *Interpreter, arguments []any) any {
+			start := l.index(interpreter.callSite(), arguments[0], len(l.elements)+1)
+			end := l.index(interpreter.callSite(), arguments[1], len(l.elements)+1)
+			if end < start {
+				panic(newRuntimeError(interpreter.callSite(), "Slice end must not be before its start."))
+			}
+			interpreter.allocate(interpreter.callSite())
+			elements := make([]any, end-start)
+			copy(elements, l.elements[start:end])
+			return newLoxList(elements)
+		})
+	}
+
+	panic(newRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme)))
+}
+
+func (l *loxList) getAt(bracket *Token, index any) any {
+	return l.elements[l.index(bracket, index, len(l.elements))]
+}
+
+func (l *loxList) setAt(bracket *Token, index any, value any) {
+	l.elements[l.index(bracket, index, len(l.elements))] = value
+}
+
+// index checks that value is a whole number from 0 up to, but not including,
+// limit and returns it.
+func (l *loxList) index(token *Token, value any, limit int) int {
+	return checkIndex(token, value, limit, "List")
+}
+
+// checkIndex is index for a sequence of the given kind, which names it in
+// error messages.
+func checkIndex(token *Token, value any, limit int, kind string) int {
+	n, ok := value.(float64)
+	if !ok || n != math.Trunc(n) {
+		panic(newRuntimeError(token, kind+" index must be an integer."))
+	}
+	if n < 0 || n >= float64(limit) {
+		panic(newRuntimeError(token, kind+" index out of range."))
+	}
+	return int(n)
+}
+
+func (l *loxList) String() string {
+	return l.format(map[any]bool{})
+}
+
+func (l *loxList) format(seen map[any]bool) string {
+	seen[l] = true
+	defer delete(seen, l)
+
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i, element := range l.elements {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(stringifyElement(element, seen))
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// stringifyElement formats a value held in a collection. Strings are quoted so
+// ["a, b"] and ["a", "b"] read differently, and a collection that contains
+// itself is shown as [...] rather than followed forever.
+func stringifyElement(value any, seen map[any]bool) string {
+	switch value := value.(type) {
+	case string:
+		return strconv.Quote(value)
+	case *loxList:
+		if seen[value] {
+			return "[...]"
+		}
+		return value.format(seen)
+	case *loxMap:
+		if seen[value] {
+			return "{...}"
+		}
+		return value.format(seen)
+	}
+	return Stringify(value)
+}
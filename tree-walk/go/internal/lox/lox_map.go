@@ -0,0 +1,120 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// loxMap is a map from any Lox value to another. Keys are compared the way
+// Interpreter.isEqual compares values: by value for nil, booleans, numbers and
+// strings, and by identity for everything else. Keys keep the order they were
+// first added in.
+type loxMap struct {
+	entries map[any]any
+	keys    []any
+}
+
+func newLoxMap() *loxMap {
+	return &loxMap{
+		entries: make(map[any]any),
+	}
+}
+
+func (m *loxMap) get(name *Token) any {
+	switch name.Lexeme {
+	case "length":
+		return float64(len(m.keys))
+	case "has":
+		return newNativeFunction("has", 1, func(interpreter *Interpreter, arguments []any) any {
+			_, ok := m.entries[arguments[0]]
+			return ok
+		})
+	case "remove":
+		return newNativeFunction("remove", 1, func(interpreter *Interpreter, arguments []any) any {
+			return m.remove(arguments[0])
+		})
+	case "keys":
+		return newNativeFunction("keys", 0, func(interpreter *Interpreter, arguments []any) any {
+			interpreter.allocate(interpreter.callSite())
+			keys := make([]any, len(m.keys))
+			copy(keys, m.keys)
+			return newLoxList(keys)
+		})
+	case "values":
+		return newNativeFunction("values", 0, func(interpreter *Interpreter, arguments []any) any {
+			interpreter.allocate(interpreter.callSite())
+			values := make([]any, 0, len(m.keys))
+			for _, key := range m.keys {
+				values = append(values, m.entries[key])
+			}
+			return newLoxList(values)
+		})
+	}
+
+	panic(newRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme)))
+}
+
+func (m *loxMap) getAt(bracket *Token, key any) any {
+	value, ok := m.entries[key]
+	if !ok {
+		panic(newRuntimeError(bracket, fmt.Sprintf("Undefined key %s.", stringifyElement(key, map[any]bool{}))))
+	}
+	return value
+}
+
+// setAt sets the value of key, which token is the bracket or brace of. A NaN
+// key is an error, since it equals nothing, itself included, and so could
+// never be looked up again.
+func (m *loxMap) setAt(token *Token, key any, value any) {
+	if n, ok := key.(float64); ok && math.IsNaN(n) {
+		panic(newRuntimeError(token, "Map key can't be NaN."))
+	}
+	m.put(key, value)
+}
+
+func (m *loxMap) put(key any, value any) {
+	if _, ok := m.entries[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.entries[key] = value
+}
+
+// remove deletes key and returns the value it had, or nil if it was absent.
+func (m *loxMap) remove(key any) any {
+	value, ok := m.entries[key]
+	if !ok {
+		return nil
+	}
+
+	delete(m.entries, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+	return value
+}
+
+func (m *loxMap) String() string {
+	return m.format(map[any]bool{})
+}
+
+func (m *loxMap) format(seen map[any]bool) string {
+	seen[m] = true
+	defer delete(seen, m)
+
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, key := range m.keys {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(stringifyElement(key, seen))
+		sb.WriteString(": ")
+		sb.WriteString(stringifyElement(m.entries[key], seen))
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
@@ -0,0 +1,140 @@
+package lox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loxModule is a script file. Its top-level declarations live in globals and
+// are read as properties of the module.
+type loxModule struct {
+	// path is the canonical path of the file, or empty for source passed to
+	// Interpreter.Run.
+	path    string
+	globals *environment
+}
+
+func newLoxModule(path string, builtins *environment) *loxModule {
+	return &loxModule{
+		path:    path,
+		globals: newGlobalEnvironment(builtins, builtins.strings),
+	}
+}
+
+func (m *loxModule) get(name *Token) any {
+	if value, ok := m.globals.values[m.globals.strings.lookup(name)]; ok {
+		return value
+	}
+
+	panic(newRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme)))
+}
+
+func (m *loxModule) String() string {
+	return "<module " + strings.TrimSuffix(filepath.Base(m.path), filepath.Ext(m.path)) + ">"
+}
+
+// enterModule makes m the module whose globals are in scope and returns the
+// module that was, for exitModule to restore. A runtime error leaves m in
+// place, so whatever recovers from the error can tell where it occurred.
+func (i *Interpreter) enterModule(m *loxModule) *loxModule {
+	previous := i.module
+	i.module = m
+	i.globals = m.globals
+	return previous
+}
+
+func (i *Interpreter) exitModule(previous *loxModule) {
+	i.module = previous
+	i.globals = previous.globals
+}
+
+// locate records that err occurred in the current module, unless it is
+// already known where it occurred.
+func (i *Interpreter) locate(err *RuntimeError) {
+	if !err.located {
+		err.Path = i.module.path
+		err.located = true
+	}
+}
+
+// importModule returns the module for the file at path, relative to the
+// importing module, running the file if this is its first import. Importing
+// needs the fs:read capability for the file.
+func (i *Interpreter) importModule(pathToken *Token) *loxModule {
+	path, _ := pathToken.Literal.(string)
+	if !filepath.IsAbs(path) && i.module.path != "" {
+		path = filepath.Join(filepath.Dir(i.module.path), path)
+	}
+	// Checking before opening the file keeps a script from learning whether
+	// files it may not read exist.
+	i.checkPathAt(pathToken, CapFSRead, path)
+	path, err := canonicalPath(path)
+	if err != nil {
+		panic(newRuntimeError(pathToken, fmt.Sprintf("Can't open module '%s'.", pathToken.Literal)))
+	}
+
+	if m, ok := i.modules[path]; ok {
+		// A module still being run has imported itself, directly or not.
+		if m == nil {
+			panic(newRuntimeError(pathToken, fmt.Sprintf("Import cycle through module '%s'.", pathToken.Literal)))
+		}
+		return m
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		panic(newRuntimeError(pathToken, fmt.Sprintf("Can't open module '%s'.", pathToken.Literal)))
+	}
+
+	statements, diagnostics := i.compile(string(source))
+	if len(diagnostics) > 0 {
+		messages := make([]string, len(diagnostics))
+		for j, diagnostic := range diagnostics {
+			messages[j] = diagnostic.Error()
+		}
+		panic(newRuntimeError(pathToken, fmt.Sprintf("Can't compile module '%s': %s", pathToken.Literal, strings.Join(messages, " "))))
+	}
+
+	i.modules[path] = nil
+	m := newLoxModule(path, i.builtins)
+	i.runModule(m, statements)
+	i.modules[path] = m
+	return m
+}
+
+func (i *Interpreter) runModule(m *loxModule, statements []Stmt) {
+	previousEnvironment := i.environment
+	previous := i.enterModule(m)
+	defer func() {
+		r := recover()
+		if err, ok := r.(*RuntimeError); ok {
+			i.locate(err)
+		}
+		i.environment = previousEnvironment
+		i.exitModule(previous)
+		if r != nil {
+			// Let a later import try the module again.
+			delete(i.modules, m.path)
+			panic(r)
+		}
+	}()
+
+	i.environment = m.globals
+	for _, statement := range statements {
+		if i.execute(statement) == completionThrow {
+			panic(i.thrown)
+		}
+	}
+}
+
+// canonicalPath is the absolute path of the file with symbolic links
+// resolved, so each file is one module however it is imported.
+func canonicalPath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(path)
+}
@@ -0,0 +1,124 @@
+package lox_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+func TestModuleErrorPath(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "main.lox")
+	if err := os.WriteFile(script, []byte(`import "lib/fail.lox" as lib;
+lib.fail();`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	module := filepath.Join(dir, "lib", "fail.lox")
+	if err := os.WriteFile(module, []byte(`fun fail() {
+  return nil + 1;
+}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := lox.NewInterpreter(lox.WithCapabilities(lox.CapFSRead)).RunFile(script)
+	runtimeErr, ok := err.(*lox.RuntimeError)
+	if !ok {
+		t.Fatalf("expected a runtime error, got %v", err)
+	}
+
+	want, _ := filepath.EvalSymlinks(module)
+	if runtimeErr.Path != want {
+		t.Errorf("expected error in %s, got %s", want, runtimeErr.Path)
+	}
+	if runtimeErr.Token.Line != 2 {
+		t.Errorf("expected error on line 2, got %d", runtimeErr.Token.Line)
+	}
+}
+
+// TestRunFileThenImport checks that a file run with RunFile, as the REPL's
+// :load does, is not left standing in for its module once it has run.
+func TestRunFileThenImport(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := filepath.Join(dir, "first.lox")
+	second := filepath.Join(dir, "second", "second.lox")
+	if err := os.Mkdir(filepath.Dir(second), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for path, source := range map[string]string{
+		first:  `var name = "first";`,
+		second: `var name = "second";`,
+		// Only a relative import from second.lox would find this one.
+		filepath.Join(dir, "second", "first.lox"): `var name = "wrong";`,
+	} {
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout bytes.Buffer
+	interpreter := lox.NewInterpreter(lox.WithOutput(&stdout), lox.WithCapabilities(lox.CapFSRead))
+	for _, path := range []string{first, second} {
+		if _, _, err := interpreter.RunFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Relative imports from Run are found from the working directory.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	_, _, err = interpreter.Run(`import "first.lox" as first;
+print first.name;
+print name;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "first\nsecond\n"; stdout.String() != want {
+		t.Errorf("expected output %q, got %q", want, stdout.String())
+	}
+}
+
+// TestModuleAssignsBuiltin checks that a module assigning to a builtin
+// changes it only in that module.
+func TestModuleAssignsBuiltin(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, source := range map[string]string{
+		"lib.lox":   "clock = nil;\nvar cleared = clock == nil;",
+		"other.lox": "var c = clock;",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout bytes.Buffer
+	interpreter := lox.NewInterpreter(lox.WithOutput(&stdout), lox.WithCapabilities(lox.CapClock, lox.CapFSRead))
+	_, _, err = interpreter.Run(`import "` + filepath.Join(dir, "lib.lox") + `" as lib;
+import "` + filepath.Join(dir, "other.lox") + `" as other;
+print lib.cleared;
+print clock == nil;
+print other.c == nil;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "true\nfalse\nfalse\n"; stdout.String() != want {
+		t.Errorf("expected output %q, got %q", want, stdout.String())
+	}
+}
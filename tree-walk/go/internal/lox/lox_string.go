@@ -0,0 +1,184 @@
+package lox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Strings are Go strings. Their length and indexes count characters rather
+// than bytes.
+
+// stringProperty returns the length of s or one of its methods.
+func (i *Interpreter) stringProperty(s string, name *Token) any {
+	switch name.Lexeme {
+	case "length":
+		return float64(i.characters(s).length())
+	case "upper":
+		return newNativeFunction("upper", 0, func(interpreter *Interpreter, arguments []any) any {
+			interpreter.allocate(interpreter.callSite())
+			return strings.ToUpper(s)
+		})
+	case "lower":
+		return newNativeFunction("lower", 0, func(interpreter *Interpreter, arguments []any) any {
+			interpreter.allocate(interpreter.callSite())
+			return strings.ToLower(s)
+		})
+	case "trim":
+		return newNativeFunction("trim", 0, func(interpreter *Interpreter, arguments []any) any {
+			interpreter.allocate(interpreter.callSite())
+			return strings.TrimSpace(s)
+		})
+	case "split":
+		return newNativeFunction("split", 1, func(interpreter *Interpreter, arguments []any) any {
+			parts := strings.Split(s, stringArgument(interpreter, arguments[0]))
+			elements := make([]any, len(parts))
+			for j, part := range parts {
+				interpreter.allocate(interpreter.callSite())
+				elements[j] = part
+			}
+			interpreter.allocate(interpreter.callSite())
+			return newLoxList(elements)
+		})
+	case "contains":
+		return newNativeFunction("contains", 1, func(interpreter *Interpreter, arguments []any) any {
+			return strings.Contains(s, stringArgument(interpreter, arguments[0]))
+		})
+	case "indexOf":
+		return newNativeFunction("indexOf", 1, func(interpreter *Interpreter, arguments []any) any {
+			index := strings.Index(s, stringArgument(interpreter, arguments[0]))
+			if index < 0 {
+				return float64(-1)
+			}
+			return float64(utf8.RuneCountInString(s[:index]))
+		})
+	case "replace":
+		return newNativeFunction("replace", 2, func(interpreter *Interpreter, arguments []any) any {
+			old := stringArgument(interpreter, arguments[0])
+			interpreter.allocate(interpreter.callSite())
+			return strings.ReplaceAll(s, old, stringArgument(interpreter, arguments[1]))
+		})
+	case "substring":
+		return newNativeFunction("substring", 2, func(interpreter *Interpreter, arguments []any) any {
+			characters := interpreter.characters(s)
+			start := checkIndex(interpreter.callSite(), arguments[0], characters.length()+1, "String")
+			end := checkIndex(interpreter.callSite(), arguments[1], characters.length()+1, "String")
+			if end < start {
+				panic(newRuntimeError(interpreter.callSite(), "Substring end must not be before its start."))
+			}
+			interpreter.allocate(interpreter.callSite())
+			return characters.slice(start, end)
+		})
+	}
+
+	panic(newRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme)))
+}
+
+// stringAt returns the character of s at index as a string.
+func (i *Interpreter) stringAt(s string, bracket *Token, index any) any {
+	characters := i.characters(s)
+	j := checkIndex(bracket, index, characters.length(), "String")
+	return characters.slice(j, j+1)
+}
+
+// characterIndex finds the characters of a string by their index.
+type characterIndex struct {
+	s string
+	// offsets holds the byte offset of each character of s followed by
+	// len(s), or is nil if s is ASCII and each character is one byte.
+	offsets []int
+}
+
+// characters returns the index of s. The interpreter keeps the index of the
+// last string it was asked for, so a loop over the characters of a string
+// doesn't scan it from the start for each one.
+func (i *Interpreter) characters(s string) *characterIndex {
+	if i.lastCharacters.s == s {
+		return &i.lastCharacters
+	}
+
+	i.lastCharacters = characterIndex{s: s}
+	for j := 0; j < len(s); j++ {
+		if s[j] >= utf8.RuneSelf {
+			offsets := make([]int, 0, utf8.RuneCountInString(s)+1)
+			for offset := range s {
+				offsets = append(offsets, offset)
+			}
+			i.lastCharacters.offsets = append(offsets, len(s))
+			break
+		}
+	}
+	return &i.lastCharacters
+}
+
+func (c *characterIndex) length() int {
+	if c.offsets == nil {
+		return len(c.s)
+	}
+	return len(c.offsets) - 1
+}
+
+// slice returns the characters of c from start up to, but not including, end.
+func (c *characterIndex) slice(start, end int) string {
+	if c.offsets == nil {
+		return c.s[start:end]
+	}
+	return c.s[c.offsets[start]:c.offsets[end]]
+}
+
+// stringArgument panics unless the argument to a string method is a string.
+func stringArgument(interpreter *Interpreter, argument any) string {
+	s, ok := argument.(string)
+	if !ok {
+		panic(newRuntimeError(interpreter.callSite(), "Argument must be a string."))
+	}
+	return s
+}
+
+// defineStringNatives defines str() and num(), which convert between numbers
+// and strings.
+func (i *Interpreter) defineStringNatives() {
+	i.DefineNative("str", 1, func(args []Value) (Value, error) {
+		return Stringify(args[0]), nil
+	})
+	// num returns nil for a string that is not a number, so scripts can
+	// check input without catching an error.
+	i.DefineNative("num", 1, func(args []Value) (Value, error) {
+		switch value := args[0].(type) {
+		case float64:
+			return value, nil
+		case string:
+			text := strings.TrimSpace(value)
+			if !isNumber(text) {
+				return nil, nil
+			}
+			n, _ := strconv.ParseFloat(text, 64)
+			return n, nil
+		}
+		return nil, fmt.Errorf("Can't convert %s to a number.", typeName(args[0]))
+	})
+}
+
+// isNumber reports whether s is a Lox number literal, optionally negated.
+// ParseFloat also accepts forms such as "inf", "0x10" and "1e5", which Lox
+// does not.
+func isNumber(s string) bool {
+	digits := func(s string) int {
+		n := 0
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		return n
+	}
+
+	s = strings.TrimPrefix(s, "-")
+	n := digits(s)
+	if n == 0 {
+		return false
+	}
+	if n == len(s) {
+		return true
+	}
+	return s[n] == '.' && n+1 < len(s) && digits(s[n+1:]) == len(s)-n-1
+}
@@ -0,0 +1,120 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// WithRandomSeed seeds the generator behind random() and randomInt() so a
+// script gets the same numbers each time it runs.
+func WithRandomSeed(seed int64) Option {
+	return func(i *Interpreter) {
+		i.random = rand.New(rand.NewSource(seed))
+	}
+}
+
+// defineMathNatives defines the math functions and constants.
+func (i *Interpreter) defineMathNatives() {
+	if i.random == nil {
+		i.random = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	i.builtins.define("PI", math.Pi)
+	i.builtins.define("E", math.E)
+
+	unary := map[string]func(float64) float64{
+		"sqrt":  math.Sqrt,
+		"floor": math.Floor,
+		"ceil":  math.Ceil,
+		"round": math.Round,
+		"abs":   math.Abs,
+		"sin":   math.Sin,
+		"cos":   math.Cos,
+		"tan":   math.Tan,
+		"asin":  math.Asin,
+		"acos":  math.Acos,
+		"atan":  math.Atan,
+		"log":   math.Log,
+		"exp":   math.Exp,
+	}
+	for name, fn := range unary {
+		fn := fn
+		i.DefineNative(name, 1, func(args []Value) (Value, error) {
+			x, ok := args[0].(float64)
+			if !ok {
+				return nil, errors.New("Argument must be a number.")
+			}
+			return fn(x), nil
+		})
+	}
+
+	binary := map[string]func(float64, float64) float64{
+		"pow":   math.Pow,
+		"atan2": math.Atan2,
+	}
+	for name, fn := range binary {
+		fn := fn
+		i.DefineNative(name, 2, func(args []Value) (Value, error) {
+			x, xok := args[0].(float64)
+			y, yok := args[1].(float64)
+			if !xok || !yok {
+				return nil, errors.New("Arguments must be numbers.")
+			}
+			return fn(x, y), nil
+		})
+	}
+
+	i.DefineVariadicNative("min", extremum(math.Min))
+	i.DefineVariadicNative("max", extremum(math.Max))
+
+	i.DefineNative("random", 0, func(args []Value) (Value, error) {
+		return i.random.Float64(), nil
+	})
+	i.DefineNative("randomInt", 2, func(args []Value) (Value, error) {
+		low, lok := args[0].(float64)
+		high, hok := args[1].(float64)
+		if !lok || !hok || !isInteger(low) || !isInteger(high) {
+			return nil, errors.New("Bounds must be integers.")
+		}
+		if high < low {
+			return nil, errors.New("Upper bound must not be less than the lower bound.")
+		}
+		// Beyond 2^53 not every integer is a number, and the range could
+		// overflow the generator.
+		if high-low > 1<<53 {
+			return nil, errors.New("Range of bounds must be at most 2^53.")
+		}
+		return low + float64(i.random.Int63n(int64(high-low)+1)), nil
+	})
+}
+
+// isInteger reports whether x is a finite whole number.
+func isInteger(x float64) bool {
+	return !math.IsInf(x, 0) && x == math.Trunc(x)
+}
+
+// extremum returns a native function that folds its arguments with pick.
+func extremum(pick func(float64, float64) float64) NativeFunc {
+	return func(args []Value) (Value, error) {
+		if len(args) == 0 {
+			return nil, errors.New("Expected at least 1 argument but got 0.")
+		}
+
+		result := math.NaN()
+		for j, arg := range args {
+			x, ok := arg.(float64)
+			if !ok {
+				return nil, fmt.Errorf("Argument %d must be a number.", j+1)
+			}
+			if j == 0 {
+				result = x
+			} else {
+				result = pick(result, x)
+			}
+		}
+		return result, nil
+	}
+}
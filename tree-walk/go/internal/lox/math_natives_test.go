@@ -0,0 +1,25 @@
+package lox_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+func TestRandomSeed(t *testing.T) {
+	source := "print random(); print randomInt(1, 1000000);"
+	outputs := make([]string, 2)
+	for j := range outputs {
+		var stdout bytes.Buffer
+		interpreter := lox.NewInterpreter(lox.WithOutput(&stdout), lox.WithRandomSeed(42))
+		if _, _, err := interpreter.Run(source); err != nil {
+			t.Fatal(err)
+		}
+		outputs[j] = stdout.String()
+	}
+
+	if outputs[0] != outputs[1] {
+		t.Errorf("expected the same numbers from the same seed, got %q and %q", outputs[0], outputs[1])
+	}
+}
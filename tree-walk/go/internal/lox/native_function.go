@@ -0,0 +1,67 @@
+package lox
+
+import "time"
+
+// variadic is the arity of a native function that takes any number of
+// arguments.
+const variadic = -1
+
+// NativeFunc is a Go function that Lox code can call. Numbers are passed and
+// should be returned as float64. A non-nil error becomes a runtime error at
+// the call.
+type NativeFunc func(args []Value) (Value, error)
+
+// DefineNative makes fn available to every module as a global function
+// taking arity arguments. A string, list or map it returns counts toward
+// Limits.MaxAllocations.
+func (i *Interpreter) DefineNative(name string, arity int, fn NativeFunc) {
+	i.builtins.define(name, newNativeFunction(name, arity, func(interpreter *Interpreter, arguments []any) any {
+		result, err := fn(arguments)
+		if err != nil {
+			panic(newRuntimeError(interpreter.callSite(), err.Error()))
+		}
+		interpreter.allocateResult(interpreter.callSite(), result)
+		return result
+	}))
+}
+
+// DefineVariadicNative is DefineNative for a function taking any number of
+// arguments.
+func (i *Interpreter) DefineVariadicNative(name string, fn NativeFunc) {
+	i.DefineNative(name, variadic, fn)
+}
+
+// nativeFunction is a function implemented in Go, such as a method of one of
+// the built-in types.
+type nativeFunction struct {
+	name   string
+	params int
+	fn     func(interpreter *Interpreter, arguments []any) any
+}
+
+func newNativeFunction(name string, arity int, fn func(interpreter *Interpreter, arguments []any) any) *nativeFunction {
+	return &nativeFunction{
+		name:   name,
+		params: arity,
+		fn:     fn,
+	}
+}
+
+func (f *nativeFunction) arity() int {
+	return f.params
+}
+
+func (f *nativeFunction) call(interpreter *Interpreter, arguments []any) any {
+	return f.fn(interpreter, arguments)
+}
+
+func (f *nativeFunction) String() string {
+	return "<native fn>"
+}
+
+// defineClock defines clock(), the seconds since the Unix epoch.
+func (i *Interpreter) defineClock() {
+	i.DefineNative("clock", 0, func(args []Value) (Value, error) {
+		return float64(time.Now().UnixNano()) / float64(time.Second), nil
+	})
+}
@@ -0,0 +1,53 @@
+package lox_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+func TestDefineNative(t *testing.T) {
+	var stdout bytes.Buffer
+	interpreter := lox.NewInterpreter(lox.WithOutput(&stdout))
+	interpreter.DefineNative("half", 1, func(args []lox.Value) (lox.Value, error) {
+		n, ok := args[0].(float64)
+		if !ok {
+			return nil, errors.New("Argument must be a number.")
+		}
+		return n / 2, nil
+	})
+	interpreter.DefineVariadicNative("count", func(args []lox.Value) (lox.Value, error) {
+		return float64(len(args)), nil
+	})
+
+	_, diagnostics, err := interpreter.Run(`print half(3);
+print count();
+print count(1, "two", nil);
+fun f() { return half("x"); }
+f();`)
+	if len(diagnostics) > 0 {
+		t.Fatalf("unexpected diagnostics %v", diagnostics)
+	}
+	if got, want := stdout.String(), "1.5\n0\n3\n"; got != want {
+		t.Errorf("expected output %q, got %q", want, got)
+	}
+
+	runtimeErr, ok := err.(*lox.RuntimeError)
+	if !ok {
+		t.Fatalf("expected a runtime error, got %v", err)
+	}
+	if runtimeErr.Message != "Argument must be a number." || runtimeErr.Token.Line != 4 {
+		t.Errorf("expected error at the call on line 4, got %q on line %d", runtimeErr.Message, runtimeErr.Token.Line)
+	}
+	want := []lox.StackFrame{
+		{Function: "half", Line: 4},
+		{Function: "f", Line: 4},
+		{Function: "script", Line: 5},
+	}
+	if fmt.Sprint(runtimeErr.StackTrace) != fmt.Sprint(want) {
+		t.Errorf("expected stack trace %v, got %v", want, runtimeErr.StackTrace)
+	}
+}
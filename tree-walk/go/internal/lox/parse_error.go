@@ -1,13 +1,27 @@
 package lox
 
-type parseError struct {
+import "fmt"
+
+type ParseError struct {
+	Token   *Token
+	Message string
+}
+
+func newParseError(token *Token, message string) *ParseError {
+	return &ParseError{
+		Token:   token,
+		Message: message,
+	}
+}
+
+func (e *ParseError) Position() (int, string) {
+	return e.Token.Line, e.Token.Lexeme
 }
 
-func newParseError(token *Token, message string) parseError {
-	errorWithToken(token, message)
-	return parseError{}
+func (e *ParseError) Span() Span {
+	return e.Token.Span()
 }
 
-func (e *parseError) Error() string {
-	return "parse error"
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("[line %d] Error%s: %s", e.Token.Line, where(e.Token), e.Message)
 }
@@ -7,12 +7,14 @@ import (
 type Parser struct {
 	tokens  []*Token
 	current int
+	errors  []Diagnostic
 }
 
 func NewParser(tokens []*Token) *Parser {
 	return &Parser{
 		tokens:  tokens,
 		current: 0,
+		errors:  make([]Diagnostic, 0),
 	}
 }
 
@@ -24,10 +26,15 @@ func (p *Parser) Parse() []Stmt {
 	return statements
 }
 
+// Errors returns the syntax errors found by the last call to Parse.
+func (p *Parser) Errors() []Diagnostic {
+	return p.errors
+}
+
 func (p *Parser) declaration() Stmt {
 	defer func() {
 		if err := recover(); err != nil {
-			if _, ok := err.(parseError); ok {
+			if _, ok := err.(*ParseError); ok {
 				p.synchronize()
 				return
 			}
@@ -35,14 +42,20 @@ func (p *Parser) declaration() Stmt {
 		}
 	}()
 
+	start := p.peek()
 	if p.match(TokenTypeClass) {
-		return p.classDeclaration()
+		return p.stmtFrom(start, p.classDeclaration())
 	}
-	if p.match(TokenTypeFun) {
-		return p.function("function")
+	// A "fun" not followed by a name starts an anonymous function expression.
+	if p.check(TokenTypeFun) && p.checkNext(TokenTypeIdentifier) {
+		p.advance()
+		return p.stmtFrom(start, p.function("function"))
 	}
 	if p.match(TokenTypeVar) {
-		return p.varDeclaration()
+		return p.stmtFrom(start, p.varDeclaration())
+	}
+	if p.match(TokenTypeImport) {
+		return p.stmtFrom(start, p.importDeclaration())
 	}
 
 	return p.statement()
@@ -55,13 +68,14 @@ func (p *Parser) classDeclaration() Stmt {
 	if p.match(TokenTypeLess) {
 		p.consume(TokenTypeIdentifier, "Expect superclass name.")
 		superclass = NewVariable(p.previous())
+		superclass.setSpan(p.previous().Span())
 	}
 
 	p.consume(TokenTypeLeftBrace, "Expect '{' before class body.")
 
 	methods := make([]*Function, 0)
 	for !p.check(TokenTypeRightBrace) && !p.isAtEnd() {
-		method := p.function("method").(*Function)
+		method := p.stmtFrom(p.peek(), p.function("method")).(*Function)
 		methods = append(methods, method)
 	}
 
@@ -73,11 +87,17 @@ func (p *Parser) classDeclaration() Stmt {
 func (p *Parser) function(kind string) Stmt {
 	name := p.consume(TokenTypeIdentifier, fmt.Sprintf("Expect %s name.", kind))
 	p.consume(TokenTypeLeftParen, fmt.Sprintf("Expect '(' after %s name.", kind))
+	return p.functionBody(kind, name)
+}
+
+// functionBody parses the parameters and body of a function after its "(".
+// The name is nil for an anonymous function.
+func (p *Parser) functionBody(kind string, name *Token) *Function {
 	parameters := make([]*Token, 0)
 	if !p.check(TokenTypeRightParen) {
 		for {
 			if len(parameters) >= 255 {
-				newParseError(p.peek(), "Can't have more than 255 parameters.")
+				p.error(p.peek(), "Can't have more than 255 parameters.")
 			}
 
 			parameters = append(parameters, p.consume(TokenTypeIdentifier, "Expect parameter name."))
@@ -93,6 +113,21 @@ func (p *Parser) function(kind string) Stmt {
 	return NewFunction(name, parameters, body)
 }
 
+func (p *Parser) importDeclaration() Stmt {
+	keyword := p.previous()
+	path := p.consume(TokenTypeString, "Expect module path after 'import'.")
+
+	// "as" is only special here, so it can still name variables.
+	if !p.check(TokenTypeIdentifier) || p.peek().Lexeme != "as" {
+		panic(p.error(p.peek(), "Expect 'as' after module path."))
+	}
+	p.advance()
+
+	name := p.consume(TokenTypeIdentifier, "Expect module name after 'as'.")
+	p.consume(TokenTypeSemicolon, "Expect ';' after import.")
+	return NewImport(keyword, path, name)
+}
+
 func (p *Parser) varDeclaration() Stmt {
 	name := p.consume(TokenTypeIdentifier, "Expect variable name.")
 
@@ -106,26 +141,39 @@ func (p *Parser) varDeclaration() Stmt {
 }
 
 func (p *Parser) statement() Stmt {
+	start := p.peek()
+	if p.match(TokenTypeBreak) {
+		return p.stmtFrom(start, p.breakStatement())
+	}
+	if p.match(TokenTypeContinue) {
+		return p.stmtFrom(start, p.continueStatement())
+	}
 	if p.match(TokenTypeFor) {
-		return p.forStatement()
+		return p.stmtFrom(start, p.forStatement())
 	}
 	if p.match(TokenTypeIf) {
-		return p.ifStatement()
+		return p.stmtFrom(start, p.ifStatement())
 	}
 	if p.match(TokenTypePrint) {
-		return p.printStatement()
+		return p.stmtFrom(start, p.printStatement())
 	}
 	if p.match(TokenTypeReturn) {
-		return p.returnStatement()
+		return p.stmtFrom(start, p.returnStatement())
+	}
+	if p.match(TokenTypeThrow) {
+		return p.stmtFrom(start, p.throwStatement())
+	}
+	if p.match(TokenTypeTry) {
+		return p.stmtFrom(start, p.tryStatement())
 	}
 	if p.match(TokenTypeWhile) {
-		return p.whileStatement()
+		return p.stmtFrom(start, p.whileStatement())
 	}
 	if p.match(TokenTypeLeftBrace) {
-		return NewBlock(p.block())
+		return p.stmtFrom(start, NewBlock(p.block()))
 	}
 
-	return p.expressionStatement()
+	return p.stmtFrom(start, p.expressionStatement())
 }
 
 func (p *Parser) block() []Stmt {
@@ -145,16 +193,29 @@ func (p *Parser) expressionStatement() Stmt {
 	return NewExpression(expr)
 }
 
+func (p *Parser) breakStatement() Stmt {
+	keyword := p.previous()
+	p.consume(TokenTypeSemicolon, "Expect ';' after 'break'.")
+	return NewBreak(keyword)
+}
+
+func (p *Parser) continueStatement() Stmt {
+	keyword := p.previous()
+	p.consume(TokenTypeSemicolon, "Expect ';' after 'continue'.")
+	return NewContinue(keyword)
+}
+
 func (p *Parser) forStatement() Stmt {
+	forToken := p.previous()
 	p.consume(TokenTypeLeftParen, "Expect '(' after 'for'.")
 
 	var initializer Stmt
 	if p.match(TokenTypeSemicolon) {
 		initializer = nil
 	} else if p.match(TokenTypeVar) {
-		initializer = p.varDeclaration()
+		initializer = p.stmtFrom(p.previous(), p.varDeclaration())
 	} else {
-		initializer = p.expressionStatement()
+		initializer = p.stmtFrom(p.peek(), p.expressionStatement())
 	}
 
 	var condition Expr = nil
@@ -171,16 +232,18 @@ func (p *Parser) forStatement() Stmt {
 
 	body := p.statement()
 
-	if increment != nil {
-		body = NewBlock([]Stmt{body, NewExpression(increment)})
-	}
-
 	if condition == nil {
 		condition = NewLiteral(true)
+		condition.setSpan(forToken.Span())
 	}
-	body = NewWhile(condition, body)
+	// The increment is kept apart from the body so that "continue" still
+	// runs it.
+	body = NewWhile(condition, body, increment)
 
+	// The statements the loop desugars into span the whole "for"; the
+	// parser's caller sets the span of the outermost.
 	if initializer != nil {
+		body.setSpan(p.spanFrom(forToken))
 		body = NewBlock([]Stmt{initializer, body})
 	}
 
@@ -218,13 +281,45 @@ func (p *Parser) returnStatement() Stmt {
 	return NewReturn(keyword, value)
 }
 
+func (p *Parser) throwStatement() Stmt {
+	keyword := p.previous()
+	value := p.expression()
+	p.consume(TokenTypeSemicolon, "Expect ';' after thrown value.")
+	return NewThrow(keyword, value)
+}
+
+func (p *Parser) tryStatement() Stmt {
+	p.consume(TokenTypeLeftBrace, "Expect '{' after 'try'.")
+	body := p.block()
+
+	var catchName *Token
+	var catchBody []Stmt
+	if p.match(TokenTypeCatch) {
+		p.consume(TokenTypeLeftParen, "Expect '(' after 'catch'.")
+		catchName = p.consume(TokenTypeIdentifier, "Expect error variable name.")
+		p.consume(TokenTypeRightParen, "Expect ')' after error variable.")
+		p.consume(TokenTypeLeftBrace, "Expect '{' before catch body.")
+		catchBody = p.block()
+	}
+
+	var finallyBody []Stmt
+	if p.match(TokenTypeFinally) {
+		p.consume(TokenTypeLeftBrace, "Expect '{' after 'finally'.")
+		finallyBody = p.block()
+	} else if catchName == nil {
+		panic(p.error(p.peek(), "Expect 'catch' or 'finally' after try block."))
+	}
+
+	return NewTry(body, catchName, catchBody, finallyBody)
+}
+
 func (p *Parser) whileStatement() Stmt {
 	p.consume(TokenTypeLeftParen, "Expect '(' after 'while'.")
 	condition := p.expression()
 	p.consume(TokenTypeRightParen, "Expect ')' after condition.")
 	body := p.statement()
 
-	return NewWhile(condition, body)
+	return NewWhile(condition, body, nil)
 }
 
 func (p *Parser) expression() Expr {
@@ -240,12 +335,14 @@ func (p *Parser) assignment() Expr {
 
 		if variable, ok := expr.(*Variable); ok {
 			name := variable.Name
-			return NewAssign(name, value)
+			return p.exprFrom(expr.Span(), NewAssign(name, value))
 		} else if get, ok := expr.(*Get); ok {
-			return NewSet(get.Object, get.Name, value)
+			return p.exprFrom(expr.Span(), NewSet(get.Object, get.Name, value))
+		} else if index, ok := expr.(*Index); ok {
+			return p.exprFrom(expr.Span(), NewSetIndex(index.Object, index.Bracket, index.Index, value))
 		}
 
-		newParseError(equals, "Invalid assignment target.")
+		p.error(equals, "Invalid assignment target.")
 	}
 
 	return expr
@@ -257,7 +354,7 @@ func (p *Parser) or() Expr {
 	for p.match(TokenTypeOr) {
 		operator := p.previous()
 		right := p.and()
-		expr = NewLogical(expr, operator, right)
+		expr = p.exprFrom(expr.Span(), NewLogical(expr, operator, right))
 	}
 
 	return expr
@@ -269,7 +366,7 @@ func (p *Parser) and() Expr {
 	for p.match(TokenTypeAnd) {
 		operator := p.previous()
 		right := p.equality()
-		expr = NewLogical(expr, operator, right)
+		expr = p.exprFrom(expr.Span(), NewLogical(expr, operator, right))
 	}
 
 	return expr
@@ -281,7 +378,7 @@ func (p *Parser) equality() Expr {
 	for p.match(TokenTypeBangEqual, TokenTypeEqualEqual) {
 		operator := p.previous()
 		right := p.comparison()
-		expr = NewBinary(expr, operator, right)
+		expr = p.exprFrom(expr.Span(), NewBinary(expr, operator, right))
 	}
 
 	return expr
@@ -293,7 +390,7 @@ func (p *Parser) comparison() Expr {
 	for p.match(TokenTypeGreater, TokenTypeGreaterEqual, TokenTypeLess, TokenTypeLessEqual) {
 		operator := p.previous()
 		right := p.term()
-		expr = NewBinary(expr, operator, right)
+		expr = p.exprFrom(expr.Span(), NewBinary(expr, operator, right))
 	}
 
 	return expr
@@ -305,7 +402,7 @@ func (p *Parser) term() Expr {
 	for p.match(TokenTypeMinus, TokenTypePlus) {
 		operator := p.previous()
 		right := p.factor()
-		expr = NewBinary(expr, operator, right)
+		expr = p.exprFrom(expr.Span(), NewBinary(expr, operator, right))
 	}
 
 	return expr
@@ -317,7 +414,7 @@ func (p *Parser) factor() Expr {
 	for p.match(TokenTypeSlash, TokenTypeStar) {
 		operator := p.previous()
 		right := p.unary()
-		expr = NewBinary(expr, operator, right)
+		expr = p.exprFrom(expr.Span(), NewBinary(expr, operator, right))
 	}
 
 	return expr
@@ -327,7 +424,7 @@ func (p *Parser) unary() Expr {
 	if p.match(TokenTypeBang, TokenTypeMinus) {
 		operator := p.previous()
 		right := p.unary()
-		return NewUnary(operator, right)
+		return p.exprFrom(operator.Span(), NewUnary(operator, right))
 	}
 
 	return p.call()
@@ -338,10 +435,15 @@ func (p *Parser) call() Expr {
 
 	for {
 		if p.match(TokenTypeLeftParen) {
-			expr = p.finishCall(expr)
+			expr = p.exprFrom(expr.Span(), p.finishCall(expr))
 		} else if p.match(TokenTypeDot) {
 			name := p.consume(TokenTypeIdentifier, "Expect property name after '.'.")
-			expr = NewGet(expr, name)
+			expr = p.exprFrom(expr.Span(), NewGet(expr, name))
+		} else if p.match(TokenTypeLeftBracket) {
+			bracket := p.previous()
+			index := p.expression()
+			p.consume(TokenTypeRightBracket, "Expect ']' after index.")
+			expr = p.exprFrom(expr.Span(), NewIndex(expr, bracket, index))
 		} else {
 			break
 		}
@@ -355,7 +457,7 @@ func (p *Parser) finishCall(callee Expr) Expr {
 	if !p.check(TokenTypeRightParen) {
 		for {
 			if len(arguments) >= 255 {
-				newParseError(p.peek(), "Can't have more than 255 arguments.")
+				p.error(p.peek(), "Can't have more than 255 arguments.")
 			}
 			arguments = append(arguments, p.expression())
 			if !p.match(TokenTypeComma) {
@@ -370,6 +472,11 @@ func (p *Parser) finishCall(callee Expr) Expr {
 }
 
 func (p *Parser) primary() Expr {
+	start := p.peek()
+	return p.exprFrom(start.Span(), p.primaryExpr())
+}
+
+func (p *Parser) primaryExpr() Expr {
 	if p.match(TokenTypeFalse) {
 		return NewLiteral(false)
 	}
@@ -405,7 +512,59 @@ func (p *Parser) primary() Expr {
 		return NewGrouping(expr)
 	}
 
-	panic(newParseError(p.peek(), "Expect expression."))
+	if p.match(TokenTypeLeftBracket) {
+		return p.list()
+	}
+
+	// A named function is a declaration, so it is not allowed here.
+	if p.check(TokenTypeFun) && !p.checkNext(TokenTypeIdentifier) {
+		keyword := p.advance()
+		p.consume(TokenTypeLeftParen, "Expect '(' after 'fun'.")
+		function := p.functionBody("function", nil)
+		function.setSpan(p.spanFrom(keyword))
+		return NewLambda(function)
+	}
+
+	if p.match(TokenTypeLeftBrace) {
+		return p.mapLiteral()
+	}
+
+	panic(p.error(p.peek(), "Expect expression."))
+}
+
+func (p *Parser) list() Expr {
+	bracket := p.previous()
+	elements := make([]Expr, 0)
+	if !p.check(TokenTypeRightBracket) {
+		for {
+			elements = append(elements, p.expression())
+			if !p.match(TokenTypeComma) {
+				break
+			}
+		}
+	}
+
+	p.consume(TokenTypeRightBracket, "Expect ']' after list elements.")
+	return NewList(bracket, elements)
+}
+
+func (p *Parser) mapLiteral() Expr {
+	brace := p.previous()
+	keys := make([]Expr, 0)
+	values := make([]Expr, 0)
+	if !p.check(TokenTypeRightBrace) {
+		for {
+			keys = append(keys, p.expression())
+			p.consume(TokenTypeColon, "Expect ':' after map key.")
+			values = append(values, p.expression())
+			if !p.match(TokenTypeComma) {
+				break
+			}
+		}
+	}
+
+	p.consume(TokenTypeRightBrace, "Expect '}' after map entries.")
+	return NewMap(brace, keys, values)
 }
 
 func (p *Parser) match(tokenTypes ...TokenType) bool {
@@ -427,6 +586,14 @@ func (p *Parser) check(tokenType TokenType) bool {
 	return p.peek().Type == tokenType
 }
 
+func (p *Parser) checkNext(tokenType TokenType) bool {
+	if p.isAtEnd() {
+		return false
+	}
+
+	return p.tokens[p.current+1].Type == tokenType
+}
+
 func (p *Parser) advance() *Token {
 	if !p.isAtEnd() {
 		p.current++
@@ -451,7 +618,31 @@ func (p *Parser) consume(tokenType TokenType, message string) *Token {
 		return p.advance()
 	}
 
-	panic(newParseError(p.peek(), message))
+	panic(p.error(p.peek(), message))
+}
+
+func (p *Parser) error(token *Token, message string) *ParseError {
+	err := newParseError(token, message)
+	p.errors = append(p.errors, err)
+	return err
+}
+
+// exprFrom sets the span of expr to run from start through the last consumed
+// token and returns expr.
+func (p *Parser) exprFrom(start Span, expr Expr) Expr {
+	expr.setSpan(start.Through(p.previous().Span()))
+	return expr
+}
+
+// stmtFrom sets the span of stmt to run from start through the last consumed
+// token and returns stmt.
+func (p *Parser) stmtFrom(start *Token, stmt Stmt) Stmt {
+	stmt.setSpan(p.spanFrom(start))
+	return stmt
+}
+
+func (p *Parser) spanFrom(start *Token) Span {
+	return start.Span().Through(p.previous().Span())
 }
 
 func (p *Parser) synchronize() {
@@ -479,6 +670,16 @@ func (p *Parser) synchronize() {
 			return
 		case TokenTypeReturn:
 			return
+		case TokenTypeBreak:
+			return
+		case TokenTypeContinue:
+			return
+		case TokenTypeThrow:
+			return
+		case TokenTypeTry:
+			return
+		case TokenTypeImport:
+			return
 		}
 
 		p.advance()
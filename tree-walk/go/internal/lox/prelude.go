@@ -0,0 +1,22 @@
+package lox
+
+// prelude is Lox code run by every new Interpreter to define the built-in
+// classes.
+const prelude = `
+class Error {
+  init(message) {
+    this.message = message;
+  }
+}
+`
+
+// runPrelude defines the built-in classes in the builtins environment.
+func (i *Interpreter) runPrelude() {
+	i.enterModule(&loxModule{globals: i.builtins})
+	i.environment = i.builtins
+	if _, diagnostics, err := i.Run(prelude); len(diagnostics) > 0 || err != nil {
+		panic("lox: invalid prelude")
+	}
+
+	i.errorClass = i.builtins.values[i.strings.symbol("Error")].(*loxClass)
+}
@@ -0,0 +1,27 @@
+package lox
+
+import "fmt"
+
+type ResolveError struct {
+	Token   *Token
+	Message string
+}
+
+func newResolveError(token *Token, message string) *ResolveError {
+	return &ResolveError{
+		Token:   token,
+		Message: message,
+	}
+}
+
+func (e *ResolveError) Position() (int, string) {
+	return e.Token.Line, e.Token.Lexeme
+}
+
+func (e *ResolveError) Span() Span {
+	return e.Token.Span()
+}
+
+func (e *ResolveError) Error() string {
+	return fmt.Sprintf("[line %d] Error%s: %s", e.Token.Line, where(e.Token), e.Message)
+}
@@ -22,6 +22,10 @@ type Resolver struct {
 	scopes              *stack
 	currentFunctionType functionType
 	currentClassType    classType
+	// loopDepth is how many loops enclose the current statement within the
+	// current function.
+	loopDepth int
+	errors    []Diagnostic
 }
 
 func NewResolver(interpreter *Interpreter) *Resolver {
@@ -30,6 +34,7 @@ func NewResolver(interpreter *Interpreter) *Resolver {
 		scopes:              newStack(),
 		currentFunctionType: functionTypeNone,
 		currentClassType:    classTypeNone,
+		errors:              make([]Diagnostic, 0),
 	}
 }
 
@@ -37,6 +42,11 @@ func (r *Resolver) Resolve(statements []Stmt) {
 	r.resolveStatements(statements)
 }
 
+// Errors returns the semantic errors found by the last call to Resolve.
+func (r *Resolver) Errors() []Diagnostic {
+	return r.errors
+}
+
 func (r *Resolver) VisitAssignExpr(expr *Assign) any {
 	r.resolveExpression(expr.Value)
 	r.resolveLocal(expr, expr.Name)
@@ -69,6 +79,24 @@ func (r *Resolver) VisitGroupingExpr(expr *Grouping) any {
 	return nil
 }
 
+func (r *Resolver) VisitIndexExpr(expr *Index) any {
+	r.resolveExpression(expr.Object)
+	r.resolveExpression(expr.Index)
+	return nil
+}
+
+func (r *Resolver) VisitLambdaExpr(expr *Lambda) any {
+	r.resolveFunction(expr.Function, functionTypeFunction)
+	return nil
+}
+
+func (r *Resolver) VisitListExpr(expr *List) any {
+	for _, element := range expr.Elements {
+		r.resolveExpression(element)
+	}
+	return nil
+}
+
 func (r *Resolver) VisitLiteralExpr(expr *Literal) any {
 	return nil
 }
@@ -79,17 +107,32 @@ func (r *Resolver) VisitLogicalExpr(expr *Logical) any {
 	return nil
 }
 
+func (r *Resolver) VisitMapExpr(expr *Map) any {
+	for j, key := range expr.Keys {
+		r.resolveExpression(key)
+		r.resolveExpression(expr.Values[j])
+	}
+	return nil
+}
+
 func (r *Resolver) VisitSetExpr(expr *Set) any {
 	r.resolveExpression(expr.Value)
 	r.resolveExpression(expr.Object)
 	return nil
 }
 
+func (r *Resolver) VisitSetIndexExpr(expr *SetIndex) any {
+	r.resolveExpression(expr.Value)
+	r.resolveExpression(expr.Object)
+	r.resolveExpression(expr.Index)
+	return nil
+}
+
 func (r *Resolver) VisitSuperExpr(expr *Super) any {
 	if r.currentClassType == classTypeNone {
-		newParseError(expr.Keyword, "Can't use 'super' outside of a class.")
+		r.error(expr.Keyword, "Can't use 'super' outside of a class.")
 	} else if r.currentClassType != classTypeSubclass {
-		newParseError(expr.Keyword, "Can't use 'super' in a class with no superclass.")
+		r.error(expr.Keyword, "Can't use 'super' in a class with no superclass.")
 	}
 	r.resolveLocal(expr, expr.Keyword)
 	return nil
@@ -97,7 +140,7 @@ func (r *Resolver) VisitSuperExpr(expr *Super) any {
 
 func (r *Resolver) VisitThisExpr(expr *This) any {
 	if r.currentClassType == classTypeNone {
-		newParseError(expr.Keyword, "Can't use 'this' outside of a class.")
+		r.error(expr.Keyword, "Can't use 'this' outside of a class.")
 		return nil
 	}
 
@@ -112,8 +155,8 @@ func (r *Resolver) VisitUnaryExpr(expr *Unary) any {
 
 func (r *Resolver) VisitVariableExpr(expr *Variable) any {
 	if !r.scopes.empty() {
-		if declared, ok := r.scopes.peek()[expr.Name.Lexeme]; ok && !declared {
-			newParseError(expr.Name, "Can't read local variable in its own initializer.")
+		if b, ok := r.scopes.peek()[expr.Name.Lexeme]; ok && !b.defined {
+			r.error(expr.Name, "Can't read local variable in its own initializer.")
 		}
 	}
 
@@ -128,6 +171,13 @@ func (r *Resolver) VisitBlockStmt(stmt *Block) any {
 	return nil
 }
 
+func (r *Resolver) VisitBreakStmt(stmt *Break) any {
+	if r.loopDepth == 0 {
+		r.error(stmt.Keyword, "Can't use 'break' outside of a loop.")
+	}
+	return nil
+}
+
 func (r *Resolver) VisitClassStmt(stmt *Class) any {
 	enclosingClass := r.currentClassType
 	r.currentClassType = classTypeClass
@@ -137,7 +187,7 @@ func (r *Resolver) VisitClassStmt(stmt *Class) any {
 
 	if stmt.Superclass != nil &&
 		stmt.Name.Lexeme == stmt.Superclass.Name.Lexeme {
-		newParseError(stmt.Superclass.Name, "A class can't inherit from itself.")
+		r.error(stmt.Superclass.Name, "A class can't inherit from itself.")
 	}
 
 	if stmt.Superclass != nil {
@@ -147,12 +197,10 @@ func (r *Resolver) VisitClassStmt(stmt *Class) any {
 
 	if stmt.Superclass != nil {
 		r.beginScope()
-		r.scopes.peek()["super"] = true
+		r.scopes.peek().declare("super")
+		r.scopes.peek().define("super")
 	}
 
-	r.beginScope()
-	r.scopes.peek()["this"] = true
-
 	for _, method := range stmt.Methods {
 		declaration := functionTypeMethod
 		if method.Name.Lexeme == "init" {
@@ -161,8 +209,6 @@ func (r *Resolver) VisitClassStmt(stmt *Class) any {
 		r.resolveFunction(method, declaration)
 	}
 
-	r.endScope()
-
 	if stmt.Superclass != nil {
 		r.endScope()
 	}
@@ -171,6 +217,13 @@ func (r *Resolver) VisitClassStmt(stmt *Class) any {
 	return nil
 }
 
+func (r *Resolver) VisitContinueStmt(stmt *Continue) any {
+	if r.loopDepth == 0 {
+		r.error(stmt.Keyword, "Can't use 'continue' outside of a loop.")
+	}
+	return nil
+}
+
 func (r *Resolver) VisitExpressionStmt(stmt *Expression) any {
 	r.resolveExpression(stmt.Expression)
 	return nil
@@ -193,6 +246,12 @@ func (r *Resolver) VisitIfStmt(stmt *If) any {
 	return nil
 }
 
+func (r *Resolver) VisitImportStmt(stmt *Import) any {
+	r.declare(stmt.Name)
+	r.define(stmt.Name)
+	return nil
+}
+
 func (r *Resolver) VisitPrintStmt(stmt *Print) any {
 	r.resolveExpression(stmt.Expression)
 	return nil
@@ -200,12 +259,12 @@ func (r *Resolver) VisitPrintStmt(stmt *Print) any {
 
 func (r *Resolver) VisitReturnStmt(stmt *Return) any {
 	if r.currentFunctionType == functionTypeNone {
-		newParseError(stmt.Keyword, "Can't return from top-level code.")
+		r.error(stmt.Keyword, "Can't return from top-level code.")
 	}
 
 	if stmt.Value != nil {
 		if r.currentFunctionType == functionTypeInitializer {
-			newParseError(stmt.Keyword, "Can't return a value from an initializer.")
+			r.error(stmt.Keyword, "Can't return a value from an initializer.")
 		}
 
 		r.resolveExpression(stmt.Value)
@@ -214,6 +273,32 @@ func (r *Resolver) VisitReturnStmt(stmt *Return) any {
 	return nil
 }
 
+func (r *Resolver) VisitThrowStmt(stmt *Throw) any {
+	r.resolveExpression(stmt.Value)
+	return nil
+}
+
+func (r *Resolver) VisitTryStmt(stmt *Try) any {
+	r.beginScope()
+	r.resolveStatements(stmt.Body)
+	r.endScope()
+
+	if stmt.CatchName != nil {
+		r.beginScope()
+		r.declare(stmt.CatchName)
+		r.define(stmt.CatchName)
+		r.resolveStatements(stmt.CatchBody)
+		r.endScope()
+	}
+
+	if stmt.FinallyBody != nil {
+		r.beginScope()
+		r.resolveStatements(stmt.FinallyBody)
+		r.endScope()
+	}
+	return nil
+}
+
 func (r *Resolver) VisitVarStmt(stmt *Var) any {
 	r.declare(stmt.Name)
 	if stmt.Initializer != nil {
@@ -225,7 +310,12 @@ func (r *Resolver) VisitVarStmt(stmt *Var) any {
 
 func (r *Resolver) VisitWhileStmt(stmt *While) any {
 	r.resolveExpression(stmt.Condition)
+	r.loopDepth++
 	r.resolveStatement(stmt.Body)
+	r.loopDepth--
+	if stmt.Increment != nil {
+		r.resolveExpression(stmt.Increment)
+	}
 	return nil
 }
 
@@ -246,8 +336,8 @@ func (r *Resolver) resolveStatements(statements []Stmt) {
 func (r *Resolver) resolveLocal(expr Expr, name *Token) {
 	l := len(r.scopes.values)
 	for i := l - 1; i >= 0; i-- {
-		if _, ok := r.scopes.values[i][name.Lexeme]; ok {
-			r.interpreter.resolve(expr, l-1-i)
+		if b, ok := r.scopes.values[i][name.Lexeme]; ok {
+			r.interpreter.resolve(expr, l-1-i, b.slot)
 			return
 		}
 	}
@@ -255,8 +345,17 @@ func (r *Resolver) resolveLocal(expr Expr, name *Token) {
 
 func (r *Resolver) resolveFunction(function *Function, functionType functionType) {
 	enclosingFunctionType := r.currentFunctionType
+	enclosingLoopDepth := r.loopDepth
 	r.currentFunctionType = functionType
+	// A loop around a function declaration does not let its body break.
+	r.loopDepth = 0
 	r.beginScope()
+	// A method finds the instance it was called on in its first slot, so
+	// calling it needs no environment of its own for "this".
+	if functionType == functionTypeMethod || functionType == functionTypeInitializer {
+		r.scopes.peek().declare("this")
+		r.scopes.peek().define("this")
+	}
 	for _, param := range function.Parameters {
 		r.declare(param)
 		r.define(param)
@@ -264,6 +363,7 @@ func (r *Resolver) resolveFunction(function *Function, functionType functionType
 	r.resolveStatements(function.Body)
 	r.endScope()
 	r.currentFunctionType = enclosingFunctionType
+	r.loopDepth = enclosingLoopDepth
 }
 
 func (r *Resolver) beginScope() {
@@ -281,15 +381,20 @@ func (r *Resolver) declare(name *Token) {
 
 	scope := r.scopes.peek()
 	if _, ok := scope[name.Lexeme]; ok {
-		newParseError(name, "Already a variable with this name in this scope.")
+		r.error(name, "Already a variable with this name in this scope.")
+		return
 	}
 
-	scope[name.Lexeme] = false
+	scope.declare(name.Lexeme)
 }
 
 func (r *Resolver) define(name *Token) {
 	if r.scopes.empty() {
 		return
 	}
-	r.scopes.peek()[name.Lexeme] = true
+	r.scopes.peek().define(name.Lexeme)
+}
+
+func (r *Resolver) error(token *Token, message string) {
+	r.errors = append(r.errors, newResolveError(token, message))
 }
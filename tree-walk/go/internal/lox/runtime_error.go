@@ -1,19 +1,78 @@
 package lox
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
-type runtimeError struct {
-	token   *Token
-	message string
+// maxTraceLines is how many stack frames RuntimeError.Error prints before
+// eliding the middle of the trace.
+const maxTraceLines = 20
+
+type RuntimeError struct {
+	Token   *Token
+	Message string
+	// StackTrace holds the calls that were active when the error occurred,
+	// innermost first.
+	StackTrace []StackFrame
+	// Value is the value thrown by a throw statement. It is nil for errors
+	// raised by the interpreter itself.
+	Value  Value
+	thrown bool
+	// Path is the module file the error occurred in, or empty if it was in
+	// the source passed to Interpreter.Run.
+	Path    string
+	located bool
+}
+
+// StackFrame is one call that was active when a runtime error occurred.
+type StackFrame struct {
+	// Function is the called function, "Class.method" for methods, or
+	// "script" for the top level.
+	Function string
+	// Line is the line that frame was executing.
+	Line int
 }
 
-func newRuntimeError(token *Token, message string) runtimeError {
-	return runtimeError{
-		token:   token,
-		message: message,
+func (f StackFrame) String() string {
+	return fmt.Sprintf("at %s (line %d)", f.Function, f.Line)
+}
+
+func newRuntimeError(token *Token, message string) *RuntimeError {
+	return &RuntimeError{
+		Token:   token,
+		Message: message,
 	}
 }
 
-func (e runtimeError) Error() string {
-	return fmt.Sprintf("%s\n[line %d]", e.message, e.token.Line)
+func (e *RuntimeError) Position() (int, string) {
+	return e.Token.Line, e.Token.Lexeme
+}
+
+func (e *RuntimeError) Span() Span {
+	return e.Token.Span()
+}
+
+func (e *RuntimeError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\n[line %d]", e.Message, e.Token.Line)
+
+	// An error at the top level needs no trace beyond its line.
+	if len(e.StackTrace) < 2 {
+		return sb.String()
+	}
+
+	trace := e.StackTrace
+	if len(trace) > maxTraceLines {
+		head, tail := maxTraceLines-5, 5
+		for _, f := range trace[:head] {
+			fmt.Fprintf(&sb, "\n  %s", f)
+		}
+		fmt.Fprintf(&sb, "\n  ... %d more ...", len(trace)-head-tail)
+		trace = trace[len(trace)-tail:]
+	}
+	for _, f := range trace {
+		fmt.Fprintf(&sb, "\n  %s", f)
+	}
+	return sb.String()
 }
@@ -0,0 +1,35 @@
+package lox_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+func TestStackTrace(t *testing.T) {
+	source := `class Foo {
+  bar() {
+    return nil + 1;
+  }
+}
+fun baz() {
+  return Foo().bar();
+}
+baz();`
+
+	_, _, err := lox.NewInterpreter().Run(source)
+	runtimeErr, ok := err.(*lox.RuntimeError)
+	if !ok {
+		t.Fatalf("expected a runtime error, got %v", err)
+	}
+
+	want := []lox.StackFrame{
+		{Function: "Foo.bar", Line: 3},
+		{Function: "baz", Line: 7},
+		{Function: "script", Line: 9},
+	}
+	if fmt.Sprint(runtimeErr.StackTrace) != fmt.Sprint(want) {
+		t.Errorf("expected stack trace %v, got %v", want, runtimeErr.StackTrace)
+	}
+}
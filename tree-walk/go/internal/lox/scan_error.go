@@ -0,0 +1,31 @@
+package lox
+
+import "fmt"
+
+type ScanError struct {
+	Line    int
+	Lexeme  string
+	Message string
+	span    Span
+}
+
+func newScanError(span Span, lexeme, message string) *ScanError {
+	return &ScanError{
+		Line:    span.Line,
+		Lexeme:  lexeme,
+		Message: message,
+		span:    span,
+	}
+}
+
+func (e *ScanError) Span() Span {
+	return e.span
+}
+
+func (e *ScanError) Position() (int, string) {
+	return e.Line, e.Lexeme
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("[line %d] Error: %s", e.Line, e.Message)
+}
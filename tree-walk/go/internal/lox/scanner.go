@@ -2,42 +2,72 @@ package lox
 
 import (
 	"strconv"
+	"unicode/utf8"
 )
 
 var keywordsTokenTypeMap = map[string]TokenType{
-	"and":    TokenTypeAnd,
-	"class":  TokenTypeClass,
-	"else":   TokenTypeElse,
-	"false":  TokenTypeFalse,
-	"for":    TokenTypeFor,
-	"fun":    TokenTypeFun,
-	"if":     TokenTypeIf,
-	"nil":    TokenTypeNil,
-	"or":     TokenTypeOr,
-	"print":  TokenTypePrint,
-	"return": TokenTypeReturn,
-	"super":  TokenTypeSuper,
-	"this":   TokenTypeThis,
-	"true":   TokenTypeTrue,
-	"var":    TokenTypeVar,
-	"while":  TokenTypeWhile,
+	"and":      TokenTypeAnd,
+	"break":    TokenTypeBreak,
+	"catch":    TokenTypeCatch,
+	"class":    TokenTypeClass,
+	"continue": TokenTypeContinue,
+	"else":     TokenTypeElse,
+	"finally":  TokenTypeFinally,
+	"false":    TokenTypeFalse,
+	"for":      TokenTypeFor,
+	"fun":      TokenTypeFun,
+	"import":   TokenTypeImport,
+	"if":       TokenTypeIf,
+	"nil":      TokenTypeNil,
+	"or":       TokenTypeOr,
+	"print":    TokenTypePrint,
+	"return":   TokenTypeReturn,
+	"super":    TokenTypeSuper,
+	"this":     TokenTypeThis,
+	"throw":    TokenTypeThrow,
+	"true":     TokenTypeTrue,
+	"try":      TokenTypeTry,
+	"var":      TokenTypeVar,
+	"while":    TokenTypeWhile,
 }
 
 type Scanner struct {
+	// text is the source code, which source holds as characters.
+	text    string
 	source  []rune
 	tokens  []*Token
 	start   int
 	current int
 	line    int
+	errors  []Diagnostic
+
+	// offset is the byte offset of current, and startOffset that of start.
+	offset      int
+	startOffset int
+	// lineStart is the index of the first character on the current line.
+	lineStart   int
+	startColumn int
+
+	// strings interns lexemes and the values of string literals.
+	strings *stringTable
 }
 
 func NewScanner(source string) *Scanner {
+	return newScanner(source, newStringTable())
+}
+
+// newScanner creates a Scanner that interns strings in table, so they are
+// shared with other sources scanned with the same table.
+func newScanner(source string, table *stringTable) *Scanner {
 	return &Scanner{
+		text:    source,
+		strings: table,
 		source:  []rune(source),
 		tokens:  make([]*Token, 0),
 		start:   0,
 		current: 0,
 		line:    1,
+		errors:  make([]Diagnostic, 0),
 	}
 }
 
@@ -45,24 +75,46 @@ func (s *Scanner) ScanTokens() []*Token {
 	for !s.isAtEnd() {
 		// We are at the start of the next lexeme.
 		s.start = s.current
+		s.startOffset = s.offset
+		s.startColumn = s.start - s.lineStart + 1
 		s.scanToken()
 	}
 
-	s.tokens = append(s.tokens, NewToken(TokenTypeEOF, "", nil, s.line))
+	s.start = s.current
+	s.startOffset = s.offset
+	s.startColumn = s.start - s.lineStart + 1
+	s.addToken(TokenTypeEOF)
 
 	return s.tokens
 }
 
+// Errors returns the errors found by the last call to ScanTokens.
+func (s *Scanner) Errors() []Diagnostic {
+	return s.errors
+}
+
 func (s *Scanner) isAtEnd() bool {
 	return s.current >= len(s.source)
 }
 
 func (s *Scanner) advance() rune {
-	r := s.source[s.current]
+	// An invalid byte is one U+FFFD in source but only one byte in text.
+	r, width := utf8.DecodeRuneInString(s.text[s.offset:])
 	s.current++
+	s.offset += width
 	return r
 }
 
+func (s *Scanner) previous() rune {
+	return s.source[s.current-1]
+}
+
+// newline starts a new line after consuming a '\n'.
+func (s *Scanner) newline() {
+	s.line++
+	s.lineStart = s.current
+}
+
 func (s *Scanner) peek() rune {
 	if s.isAtEnd() {
 		return rune(0)
@@ -88,7 +140,7 @@ func (s *Scanner) match(r rune) bool {
 		return false
 	}
 
-	s.current++
+	s.advance()
 	return true
 }
 
@@ -117,6 +169,12 @@ func (s *Scanner) scanToken() {
 		s.addToken(TokenTypeLeftBrace)
 	case '}':
 		s.addToken(TokenTypeRightBrace)
+	case '[':
+		s.addToken(TokenTypeLeftBracket)
+	case ']':
+		s.addToken(TokenTypeRightBracket)
+	case ':':
+		s.addToken(TokenTypeColon)
 	case ',':
 		s.addToken(TokenTypeComma)
 	case '.':
@@ -166,7 +224,7 @@ func (s *Scanner) scanToken() {
 	case '\t':
 		break
 	case '\n':
-		s.line++
+		s.newline()
 	case '"':
 		s.scanString()
 	default:
@@ -175,29 +233,30 @@ func (s *Scanner) scanToken() {
 		} else if s.isAlpha(r) {
 			s.scanIdentifier()
 		} else {
-			error(s.line, "Unexpected character.")
+			s.error("Unexpected character.")
 		}
 	}
 }
 
 func (s *Scanner) scanString() {
 	for s.peek() != '"' && !s.isAtEnd() {
-		if s.peek() == '\n' {
-			s.line++
-		}
 		s.advance()
+		if s.previous() == '\n' {
+			s.newline()
+		}
 	}
 
 	if s.isAtEnd() {
-		error(s.line, "Unterminated string.")
+		s.error("Unterminated string.")
 		return
 	}
 
 	// The closing ".
 	s.advance()
 
-	value := s.source[s.start+1 : s.current-1]
-	s.addTokenWithLiteral(TokenTypeString, string(value))
+	// The quotes are one byte each.
+	value := s.strings.intern(s.text[s.startOffset+1 : s.offset-1])
+	s.addTokenWithLiteral(TokenTypeString, value)
 }
 
 func (s *Scanner) scanNumber() {
@@ -214,8 +273,7 @@ func (s *Scanner) scanNumber() {
 		}
 	}
 
-	literal := string(s.source[s.start:s.current])
-	n, _ := strconv.ParseFloat(literal, 64)
+	n, _ := strconv.ParseFloat(s.text[s.startOffset:s.offset], 64)
 	s.addTokenWithLiteral(TokenTypeNumber, n)
 }
 
@@ -224,7 +282,7 @@ func (s *Scanner) scanIdentifier() {
 		s.advance()
 	}
 
-	text := string(s.source[s.start:s.current])
+	text := s.text[s.startOffset:s.offset]
 	tokenType, ok := keywordsTokenTypeMap[text]
 	if !ok {
 		tokenType = TokenTypeIdentifier
@@ -233,11 +291,36 @@ func (s *Scanner) scanIdentifier() {
 	s.addToken(tokenType)
 }
 
+func (s *Scanner) error(message string) {
+	lexeme := s.text[s.startOffset:s.offset]
+	s.errors = append(s.errors, newScanError(s.span(), lexeme, message))
+}
+
 func (s *Scanner) addToken(tokenType TokenType) {
 	s.addTokenWithLiteral(tokenType, nil)
 }
 
 func (s *Scanner) addTokenWithLiteral(tokenType TokenType, literal any) {
-	text := string(s.source[s.start:s.current])
-	s.tokens = append(s.tokens, NewToken(tokenType, text, literal, s.line))
+	text := s.text[s.startOffset:s.offset]
+	var sym *symbol
+	if tokenType == TokenTypeIdentifier {
+		sym = s.strings.symbol(text)
+		text = sym.name
+	}
+	token := NewToken(tokenType, text, literal, s.line)
+	token.symbol = sym
+	token.Offset = s.startOffset
+	token.Length = s.offset - s.startOffset
+	token.Column = s.startColumn
+	s.tokens = append(s.tokens, token)
+}
+
+// span returns the span of the current lexeme.
+func (s *Scanner) span() Span {
+	return Span{
+		Offset: s.startOffset,
+		Length: s.offset - s.startOffset,
+		Line:   s.line,
+		Column: s.startColumn,
+	}
 }
@@ -0,0 +1,29 @@
+package lox_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+// TestInvalidUTF8 checks that an invalid byte, which is one character but
+// only one byte long, doesn't throw the scanner's offsets off.
+func TestInvalidUTF8(t *testing.T) {
+	for source, want := range map[string]string{
+		"print \"\xff\";":                "\xff\n",
+		"// \xff\nprint \"after\";":      "after\n",
+		"print \"a\xffb\" + \"c\";":      "a\xffbc\n",
+		"var s = \"\xff\xfe\"; print s;": "\xff\xfe\n",
+	} {
+		var stdout bytes.Buffer
+		_, diagnostics, err := lox.NewInterpreter(lox.WithOutput(&stdout)).Run(source)
+		if len(diagnostics) > 0 || err != nil {
+			t.Errorf("%q: unexpected errors %v %v", source, diagnostics, err)
+			continue
+		}
+		if stdout.String() != want {
+			t.Errorf("%q: expected output %q, got %q", source, want, stdout.String())
+		}
+	}
+}
@@ -1,11 +1,30 @@
 package lox
 
-type scope map[string]bool
+// binding is what the Resolver knows about a local variable.
+type binding struct {
+	// slot is where the variable is kept in its environment.
+	slot int
+	// defined is false while the variable's initializer is being resolved.
+	defined bool
+}
+
+type scope map[string]binding
 
 func newScope() scope {
 	return scope{}
 }
 
+// declare adds a variable in the next free slot.
+func (s scope) declare(name string) {
+	s[name] = binding{slot: len(s)}
+}
+
+func (s scope) define(name string) {
+	b := s[name]
+	b.defined = true
+	s[name] = b
+}
+
 type stack struct {
 	values []scope
 }
@@ -16,11 +35,11 @@ func newStack() *stack {
 	}
 }
 
-func (s *stack) push(value map[string]bool) {
+func (s *stack) push(value scope) {
 	s.values = append(s.values, value)
 }
 
-func (s *stack) pop() map[string]bool {
+func (s *stack) pop() scope {
 	l := len(s.values)
 	if l == 0 {
 		panic("cannot pop value from empty stack")
@@ -35,7 +54,7 @@ func (s *stack) empty() bool {
 	return len(s.values) == 0
 }
 
-func (s *stack) peek() map[string]bool {
+func (s *stack) peek() scope {
 	l := len(s.values)
 	return s.values[l-1]
 }
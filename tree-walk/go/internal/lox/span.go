@@ -0,0 +1,83 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Span is a range of source text.
+type Span struct {
+	// Offset is the byte offset of the first character.
+	Offset int
+	// Length is the length of the range in bytes.
+	Length int
+	// Line is the line of the first character, counting from 1.
+	Line int
+	// Column is the column of the first character, counting characters
+	// from 1.
+	Column int
+}
+
+// Through returns the span from the start of s to the end of end.
+func (s Span) Through(end Span) Span {
+	s.Length = end.Offset + end.Length - s.Offset
+	return s
+}
+
+// node is embedded in every Expr and Stmt to record its span.
+type node struct {
+	span Span
+}
+
+// Span returns the source text the syntax was parsed from.
+func (n *node) Span() Span {
+	return n.span
+}
+
+func (n *node) setSpan(span Span) {
+	n.span = span
+}
+
+// Excerpt returns the source line span starts on, with a caret underline
+// beneath the span. Each line is indented so the excerpt reads as context for
+// the error message printed above it.
+func Excerpt(source string, span Span) string {
+	if span.Line == 0 || span.Offset > len(source) {
+		return ""
+	}
+
+	start := strings.LastIndexByte(source[:span.Offset], '\n') + 1
+	end := strings.IndexByte(source[span.Offset:], '\n')
+	if end < 0 {
+		end = len(source)
+	} else {
+		end += span.Offset
+	}
+	line := strings.TrimRight(source[start:end], "\r")
+
+	// Keep tabs in the padding so the caret lines up with the line above.
+	var padding strings.Builder
+	for _, r := range source[start:span.Offset] {
+		if r == '\t' {
+			padding.WriteRune('\t')
+		} else {
+			padding.WriteRune(' ')
+		}
+	}
+
+	underline := 1
+	if span.Length > 0 {
+		spanEnd := span.Offset + span.Length
+		if spanEnd > end {
+			spanEnd = end
+		}
+		if n := utf8.RuneCountInString(source[span.Offset:spanEnd]); n > 0 {
+			underline = n
+		}
+	}
+
+	gutter := fmt.Sprintf("%d", span.Line)
+	return fmt.Sprintf("  %s | %s\n  %s | %s%s",
+		gutter, line, strings.Repeat(" ", len(gutter)), padding.String(), strings.Repeat("^", underline))
+}
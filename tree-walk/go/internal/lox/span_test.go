@@ -0,0 +1,69 @@
+package lox_test
+
+import (
+	"testing"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+func TestSpans(t *testing.T) {
+	source := "var s = \"é\";\nprint s + foo.bar(1, 2);"
+	scanner := lox.NewScanner(source)
+	statements := lox.NewParser(scanner.ScanTokens()).Parse()
+	if len(statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(statements))
+	}
+
+	text := func(span lox.Span) string {
+		return source[span.Offset : span.Offset+span.Length]
+	}
+
+	print := statements[1].(*lox.Print)
+	if got := text(print.Span()); got != "print s + foo.bar(1, 2);" {
+		t.Errorf("expected print statement span, got %q", got)
+	}
+	binary := print.Expression.(*lox.Binary)
+	if got := text(binary.Right.Span()); got != "foo.bar(1, 2)" {
+		t.Errorf("expected call span, got %q", got)
+	}
+	if span := binary.Operator.Span(); span.Line != 2 || span.Column != 9 {
+		t.Errorf("expected '+' at 2:9, got %d:%d", span.Line, span.Column)
+	}
+	if got := text(statements[0].(*lox.Var).Initializer.Span()); got != "\"é\"" {
+		t.Errorf("expected string literal span, got %q", got)
+	}
+}
+
+// TestInvalidUTF8Spans checks that spans after an invalid byte still cover
+// the bytes they were scanned from.
+func TestInvalidUTF8Spans(t *testing.T) {
+	source := "var s = \"\xff\"; // \xff\xfe\nprint s + nil;"
+	statements := lox.NewParser(lox.NewScanner(source).ScanTokens()).Parse()
+	if len(statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(statements))
+	}
+
+	text := func(span lox.Span) string {
+		return source[span.Offset : span.Offset+span.Length]
+	}
+
+	if got := text(statements[0].(*lox.Var).Initializer.Span()); got != "\"\xff\"" {
+		t.Errorf("expected string literal span, got %q", got)
+	}
+	print := statements[1].(*lox.Print)
+	if got := text(print.Span()); got != "print s + nil;" {
+		t.Errorf("expected print statement span, got %q", got)
+	}
+
+	operator := print.Expression.(*lox.Binary).Operator.Span()
+	want := "  2 | print s + nil;\n    |         ^"
+	if got := lox.Excerpt(source, operator); got != want {
+		t.Errorf("expected excerpt:\n%s\ngot:\n%s", want, got)
+	}
+
+	span := statements[0].(*lox.Var).Initializer.Span()
+	want = "  1 | var s = \"\xff\"; // \xff\xfe\n    |         ^^^"
+	if got := lox.Excerpt(source, span); got != want {
+		t.Errorf("expected excerpt:\n%s\ngot:\n%s", want, got)
+	}
+}
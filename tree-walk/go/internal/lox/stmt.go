@@ -2,21 +2,29 @@ package lox
 
 type StmtVisitor interface {
 	VisitBlockStmt(stmt *Block) any
+	VisitBreakStmt(stmt *Break) any
 	VisitClassStmt(stmt *Class) any
+	VisitContinueStmt(stmt *Continue) any
 	VisitExpressionStmt(stmt *Expression) any
 	VisitFunctionStmt(stmt *Function) any
 	VisitIfStmt(stmt *If) any
+	VisitImportStmt(stmt *Import) any
 	VisitPrintStmt(stmt *Print) any
 	VisitReturnStmt(stmt *Return) any
+	VisitThrowStmt(stmt *Throw) any
+	VisitTryStmt(stmt *Try) any
 	VisitVarStmt(stmt *Var) any
 	VisitWhileStmt(stmt *While) any
 }
 
 type Stmt interface {
 	Accept(v StmtVisitor) any
+	Span() Span
+	setSpan(span Span)
 }
 
 type Block struct {
+	node
 	Statements []Stmt
 }
 
@@ -30,7 +38,23 @@ func (stmt *Block) Accept(v StmtVisitor) any {
 	return v.VisitBlockStmt(stmt)
 }
 
+type Break struct {
+	node
+	Keyword *Token
+}
+
+func NewBreak(keyword *Token) *Break {
+	return &Break{
+		Keyword: keyword,
+	}
+}
+
+func (stmt *Break) Accept(v StmtVisitor) any {
+	return v.VisitBreakStmt(stmt)
+}
+
 type Class struct {
+	node
 	Name       *Token
 	Superclass *Variable
 	Methods    []*Function
@@ -48,7 +72,23 @@ func (stmt *Class) Accept(v StmtVisitor) any {
 	return v.VisitClassStmt(stmt)
 }
 
+type Continue struct {
+	node
+	Keyword *Token
+}
+
+func NewContinue(keyword *Token) *Continue {
+	return &Continue{
+		Keyword: keyword,
+	}
+}
+
+func (stmt *Continue) Accept(v StmtVisitor) any {
+	return v.VisitContinueStmt(stmt)
+}
+
 type Expression struct {
+	node
 	Expression Expr
 }
 
@@ -63,6 +103,7 @@ func (stmt *Expression) Accept(v StmtVisitor) any {
 }
 
 type Function struct {
+	node
 	Name       *Token
 	Parameters []*Token
 	Body       []Stmt
@@ -81,6 +122,7 @@ func (stmt *Function) Accept(v StmtVisitor) any {
 }
 
 type If struct {
+	node
 	Condition  Expr
 	ThenBranch Stmt
 	ElseBranch Stmt
@@ -98,7 +140,27 @@ func (stmt *If) Accept(v StmtVisitor) any {
 	return v.VisitIfStmt(stmt)
 }
 
+type Import struct {
+	node
+	Keyword *Token
+	Path    *Token
+	Name    *Token
+}
+
+func NewImport(keyword *Token, path *Token, name *Token) *Import {
+	return &Import{
+		Keyword: keyword,
+		Path:    path,
+		Name:    name,
+	}
+}
+
+func (stmt *Import) Accept(v StmtVisitor) any {
+	return v.VisitImportStmt(stmt)
+}
+
 type Print struct {
+	node
 	Expression Expr
 }
 
@@ -113,6 +175,7 @@ func (stmt *Print) Accept(v StmtVisitor) any {
 }
 
 type Return struct {
+	node
 	Keyword *Token
 	Value   Expr
 }
@@ -128,7 +191,46 @@ func (stmt *Return) Accept(v StmtVisitor) any {
 	return v.VisitReturnStmt(stmt)
 }
 
+type Throw struct {
+	node
+	Keyword *Token
+	Value   Expr
+}
+
+func NewThrow(keyword *Token, value Expr) *Throw {
+	return &Throw{
+		Keyword: keyword,
+		Value:   value,
+	}
+}
+
+func (stmt *Throw) Accept(v StmtVisitor) any {
+	return v.VisitThrowStmt(stmt)
+}
+
+type Try struct {
+	node
+	Body        []Stmt
+	CatchName   *Token
+	CatchBody   []Stmt
+	FinallyBody []Stmt
+}
+
+func NewTry(body []Stmt, catchname *Token, catchbody []Stmt, finallybody []Stmt) *Try {
+	return &Try{
+		Body:        body,
+		CatchName:   catchname,
+		CatchBody:   catchbody,
+		FinallyBody: finallybody,
+	}
+}
+
+func (stmt *Try) Accept(v StmtVisitor) any {
+	return v.VisitTryStmt(stmt)
+}
+
 type Var struct {
+	node
 	Name        *Token
 	Initializer Expr
 }
@@ -145,14 +247,17 @@ func (stmt *Var) Accept(v StmtVisitor) any {
 }
 
 type While struct {
+	node
 	Condition Expr
 	Body      Stmt
+	Increment Expr
 }
 
-func NewWhile(condition Expr, body Stmt) *While {
+func NewWhile(condition Expr, body Stmt, increment Expr) *While {
 	return &While{
 		Condition: condition,
 		Body:      body,
+		Increment: increment,
 	}
 }
 
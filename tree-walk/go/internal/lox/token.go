@@ -7,6 +7,14 @@ type Token struct {
 	Lexeme  string
 	Literal any
 	Line    int
+	// Offset is the byte offset of the lexeme in the source.
+	Offset int
+	// Length is the length of the lexeme in bytes.
+	Length int
+	// Column is the column the lexeme starts at, counting characters from 1.
+	Column int
+	// symbol is the interned lexeme of an identifier made by a Scanner.
+	symbol *symbol
 }
 
 func NewToken(tokenType TokenType, lexeme string, literal any, line int) *Token {
@@ -18,6 +26,11 @@ func NewToken(tokenType TokenType, lexeme string, literal any, line int) *Token
 	}
 }
 
+// Span returns the source text the token was scanned from.
+func (t *Token) Span() Span {
+	return Span{Offset: t.Offset, Length: t.Length, Line: t.Line, Column: t.Column}
+}
+
 func (t *Token) String() string {
 	return fmt.Sprintf("%s %s %v", t.Type, t.Lexeme, t.Lexeme)
 }
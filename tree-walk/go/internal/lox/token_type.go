@@ -8,6 +8,9 @@ const (
 	TokenTypeRightParen
 	TokenTypeLeftBrace
 	TokenTypeRightBrace
+	TokenTypeLeftBracket
+	TokenTypeRightBracket
+	TokenTypeColon
 	TokenTypeComma
 	TokenTypeDot
 	TokenTypeMinus
@@ -33,19 +36,26 @@ const (
 
 	// Keywords
 	TokenTypeAnd
+	TokenTypeBreak
+	TokenTypeCatch
 	TokenTypeClass
+	TokenTypeContinue
 	TokenTypeElse
 	TokenTypeFalse
+	TokenTypeFinally
 	TokenTypeFun
 	TokenTypeFor
 	TokenTypeIf
+	TokenTypeImport
 	TokenTypeNil
 	TokenTypeOr
 	TokenTypePrint
 	TokenTypeReturn
 	TokenTypeSuper
 	TokenTypeThis
+	TokenTypeThrow
 	TokenTypeTrue
+	TokenTypeTry
 	TokenTypeVar
 	TokenTypeWhile
 
@@ -57,6 +67,9 @@ var tokenTypeNames = map[TokenType]string{
 	TokenTypeRightParen:   "RIGHT_PAREN",
 	TokenTypeLeftBrace:    "LEFT_BRACE",
 	TokenTypeRightBrace:   "RIGHT_BRACE",
+	TokenTypeLeftBracket:  "LEFT_BRACKET",
+	TokenTypeRightBracket: "RIGHT_BRACKET",
+	TokenTypeColon:        "COLON",
 	TokenTypeComma:        "COMMA",
 	TokenTypeDot:          "DOT",
 	TokenTypeMinus:        "MINUS",
@@ -76,19 +89,26 @@ var tokenTypeNames = map[TokenType]string{
 	TokenTypeString:       "STRING",
 	TokenTypeNumber:       "NUMBER",
 	TokenTypeAnd:          "AND",
+	TokenTypeBreak:        "BREAK",
+	TokenTypeCatch:        "CATCH",
 	TokenTypeClass:        "CLASS",
+	TokenTypeContinue:     "CONTINUE",
 	TokenTypeElse:         "ELSE",
 	TokenTypeFalse:        "FALSE",
+	TokenTypeFinally:      "FINALLY",
 	TokenTypeFun:          "FUN",
 	TokenTypeFor:          "FOR",
 	TokenTypeIf:           "IF",
+	TokenTypeImport:       "IMPORT",
 	TokenTypeNil:          "NIL",
 	TokenTypeOr:           "OR",
 	TokenTypePrint:        "PRINT",
 	TokenTypeReturn:       "RETURN",
 	TokenTypeSuper:        "SUPER",
 	TokenTypeThis:         "THIS",
+	TokenTypeThrow:        "THROW",
 	TokenTypeTrue:         "TRUE",
+	TokenTypeTry:          "TRY",
 	TokenTypeVar:          "VAR",
 	TokenTypeWhile:        "WHILE",
 	TokenTypeEOF:          "EOF",
@@ -0,0 +1,5 @@
+package lox
+
+// Value is any Lox runtime value: nil, bool, float64, string or one of the
+// interpreter's object types.
+type Value = any
@@ -0,0 +1,61 @@
+package vm
+
+type OpCode byte
+
+const (
+	OpConstant OpCode = iota
+	OpNil
+	OpTrue
+	OpFalse
+	OpPop
+	OpGetLocal
+	OpSetLocal
+	OpGetGlobal
+	OpDefineGlobal
+	OpSetGlobal
+	OpGetUpvalue
+	OpSetUpvalue
+	OpGetProperty
+	OpSetProperty
+	OpGetSuper
+	OpEqual
+	OpGreater
+	OpLess
+	OpAdd
+	OpSubtract
+	OpMultiply
+	OpDivide
+	OpNot
+	OpNegate
+	OpPrint
+	OpJump
+	OpJumpIfFalse
+	OpLoop
+	OpCall
+	OpInvoke
+	OpSuperInvoke
+	OpClosure
+	OpCloseUpvalue
+	OpReturn
+	OpClass
+	OpInherit
+	OpMethod
+)
+
+// Chunk is a sequence of bytecode along with the constants it refers to and
+// the source line of every byte.
+type Chunk struct {
+	Code      []byte
+	Lines     []int
+	Constants []Value
+}
+
+func (c *Chunk) write(b byte, line int) {
+	c.Code = append(c.Code, b)
+	c.Lines = append(c.Lines, line)
+}
+
+func (c *Chunk) addConstant(value Value) int {
+	c.Constants = append(c.Constants, value)
+	return len(c.Constants) - 1
+}
@@ -0,0 +1,983 @@
+package vm
+
+import (
+	"os"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+const (
+	debugPrintCode = false
+	uint8Count     = 256
+)
+
+type precedence int
+
+const (
+	precNone       precedence = iota
+	precAssignment            // =
+	precOr                    // or
+	precAnd                   // and
+	precEquality              // == !=
+	precComparison            // < > <= >=
+	precTerm                  // + -
+	precFactor                // * /
+	precUnary                 // ! -
+	precCall                  // . ()
+	precPrimary
+)
+
+type parseFn func(p *parser, canAssign bool)
+
+type parseRule struct {
+	prefix     parseFn
+	infix      parseFn
+	precedence precedence
+}
+
+type local struct {
+	name       *lox.Token
+	depth      int
+	isCaptured bool
+}
+
+type upvalue struct {
+	index   byte
+	isLocal bool
+}
+
+type functionType int
+
+const (
+	typeFunction functionType = iota
+	typeInitializer
+	typeMethod
+	typeScript
+)
+
+type compiler struct {
+	enclosing    *compiler
+	function     *objFunction
+	functionType functionType
+
+	locals     [uint8Count]local
+	localCount int
+	upvalues   [uint8Count]upvalue
+	scopeDepth int
+	// lastJumpTarget is the offset the most recently patched forward jump
+	// lands on.
+	lastJumpTarget int
+}
+
+type classCompiler struct {
+	enclosing     *classCompiler
+	hasSuperclass bool
+}
+
+// parser compiles a token stream into bytecode in a single pass.
+type parser struct {
+	tokens    []*lox.Token
+	next      int
+	current   *lox.Token
+	previous  *lox.Token
+	panicMode bool
+	errors    []lox.Diagnostic
+
+	strings       stringTable
+	compiler      *compiler
+	classCompiler *classCompiler
+	// lastExpression is the offset of the OpPop that ended the most recent
+	// top-level expression statement, so the script can return its value.
+	lastExpression int
+}
+
+var rules []parseRule
+
+func init() {
+	rules = make([]parseRule, lox.TokenTypeEOF+1)
+	rules[lox.TokenTypeLeftParen] = parseRule{(*parser).grouping, (*parser).call, precCall}
+	rules[lox.TokenTypeDot] = parseRule{nil, (*parser).dot, precCall}
+	rules[lox.TokenTypeMinus] = parseRule{(*parser).unary, (*parser).binary, precTerm}
+	rules[lox.TokenTypePlus] = parseRule{nil, (*parser).binary, precTerm}
+	rules[lox.TokenTypeSlash] = parseRule{nil, (*parser).binary, precFactor}
+	rules[lox.TokenTypeStar] = parseRule{nil, (*parser).binary, precFactor}
+	rules[lox.TokenTypeBang] = parseRule{(*parser).unary, nil, precNone}
+	rules[lox.TokenTypeBangEqual] = parseRule{nil, (*parser).binary, precEquality}
+	rules[lox.TokenTypeEqualEqual] = parseRule{nil, (*parser).binary, precEquality}
+	rules[lox.TokenTypeGreater] = parseRule{nil, (*parser).binary, precComparison}
+	rules[lox.TokenTypeGreaterEqual] = parseRule{nil, (*parser).binary, precComparison}
+	rules[lox.TokenTypeLess] = parseRule{nil, (*parser).binary, precComparison}
+	rules[lox.TokenTypeLessEqual] = parseRule{nil, (*parser).binary, precComparison}
+	rules[lox.TokenTypeIdentifier] = parseRule{(*parser).variable, nil, precNone}
+	rules[lox.TokenTypeString] = parseRule{(*parser).string, nil, precNone}
+	rules[lox.TokenTypeNumber] = parseRule{(*parser).number, nil, precNone}
+	rules[lox.TokenTypeAnd] = parseRule{nil, (*parser).and, precAnd}
+	rules[lox.TokenTypeFalse] = parseRule{(*parser).literal, nil, precNone}
+	rules[lox.TokenTypeNil] = parseRule{(*parser).literal, nil, precNone}
+	rules[lox.TokenTypeOr] = parseRule{nil, (*parser).or, precOr}
+	rules[lox.TokenTypeSuper] = parseRule{(*parser).super, nil, precNone}
+	rules[lox.TokenTypeThis] = parseRule{(*parser).this, nil, precNone}
+	rules[lox.TokenTypeTrue] = parseRule{(*parser).literal, nil, precNone}
+}
+
+// compile turns source into the function for the top-level script. Scan and
+// compile errors are returned as diagnostics, in which case the function is
+// nil.
+func compile(source string, strings stringTable) (*objFunction, []lox.Diagnostic) {
+	scanner := lox.NewScanner(source)
+	tokens := scanner.ScanTokens()
+	if errors := scanner.Errors(); len(errors) > 0 {
+		return nil, errors
+	}
+
+	p := &parser{
+		tokens:         tokens,
+		errors:         make([]lox.Diagnostic, 0),
+		strings:        strings,
+		lastExpression: -1,
+	}
+	p.initCompiler(&compiler{}, typeScript)
+
+	p.advance()
+
+	for !p.match(lox.TokenTypeEOF) {
+		p.declaration()
+	}
+
+	function := p.endCompiler()
+	if len(p.errors) > 0 {
+		return nil, p.errors
+	}
+	return function, nil
+}
+
+func (p *parser) currentChunk() *Chunk {
+	return &p.compiler.function.chunk
+}
+
+func (p *parser) errorAt(token *lox.Token, message string) {
+	if p.panicMode {
+		return
+	}
+	p.panicMode = true
+	p.errors = append(p.errors, &lox.ParseError{Token: token, Message: message})
+}
+
+func (p *parser) error(message string) {
+	p.errorAt(p.previous, message)
+}
+
+func (p *parser) errorAtCurrent(message string) {
+	p.errorAt(p.current, message)
+}
+
+func (p *parser) advance() {
+	p.previous = p.current
+	p.current = p.tokens[p.next]
+	if p.next < len(p.tokens)-1 {
+		p.next++
+	}
+}
+
+func (p *parser) consume(tokenType lox.TokenType, message string) {
+	if p.current.Type == tokenType {
+		p.advance()
+		return
+	}
+
+	p.errorAtCurrent(message)
+}
+
+func (p *parser) check(tokenType lox.TokenType) bool {
+	return p.current.Type == tokenType
+}
+
+func (p *parser) match(tokenType lox.TokenType) bool {
+	if !p.check(tokenType) {
+		return false
+	}
+	p.advance()
+	return true
+}
+
+func (p *parser) emitByte(b byte) {
+	p.currentChunk().write(b, p.previous.Line)
+}
+
+func (p *parser) emitBytes(b1, b2 byte) {
+	p.emitByte(b1)
+	p.emitByte(b2)
+}
+
+func (p *parser) emitOp(op OpCode) {
+	p.emitByte(byte(op))
+}
+
+func (p *parser) emitOps(op1, op2 OpCode) {
+	p.emitBytes(byte(op1), byte(op2))
+}
+
+func (p *parser) emitLoop(loopStart int) {
+	p.emitOp(OpLoop)
+
+	offset := len(p.currentChunk().Code) - loopStart + 2
+	if offset > 0xffff {
+		p.error("Loop body too large.")
+	}
+
+	p.emitByte(byte((offset >> 8) & 0xff))
+	p.emitByte(byte(offset & 0xff))
+}
+
+func (p *parser) emitJump(instruction OpCode) int {
+	p.emitOp(instruction)
+	p.emitByte(0xff)
+	p.emitByte(0xff)
+	return len(p.currentChunk().Code) - 2
+}
+
+func (p *parser) emitReturn() {
+	if p.compiler.functionType == typeInitializer {
+		p.emitBytes(byte(OpGetLocal), 0)
+	} else {
+		p.emitOp(OpNil)
+	}
+
+	p.emitOp(OpReturn)
+}
+
+func (p *parser) makeConstant(value Value) byte {
+	constant := p.currentChunk().addConstant(value)
+	if constant > 0xff {
+		p.error("Too many constants in one chunk.")
+		return 0
+	}
+
+	return byte(constant)
+}
+
+func (p *parser) emitConstant(value Value) {
+	p.emitBytes(byte(OpConstant), p.makeConstant(value))
+}
+
+func (p *parser) patchJump(offset int) {
+	// -2 to adjust for the bytecode for the jump offset itself.
+	jump := len(p.currentChunk().Code) - offset - 2
+
+	if jump > 0xffff {
+		p.error("Too much code to jump over.")
+	}
+
+	p.currentChunk().Code[offset] = byte((jump >> 8) & 0xff)
+	p.currentChunk().Code[offset+1] = byte(jump & 0xff)
+	p.compiler.lastJumpTarget = len(p.currentChunk().Code)
+}
+
+func (p *parser) initCompiler(compiler *compiler, functionType functionType) {
+	compiler.enclosing = p.compiler
+	compiler.functionType = functionType
+	compiler.function = newFunction()
+	p.compiler = compiler
+	if functionType != typeScript {
+		compiler.function.name = p.strings.copyString(p.previous.Lexeme)
+	}
+
+	local := &compiler.locals[compiler.localCount]
+	compiler.localCount++
+	local.depth = 0
+	local.isCaptured = false
+	if functionType != typeFunction {
+		local.name = syntheticToken("this")
+	} else {
+		local.name = syntheticToken("")
+	}
+}
+
+func (p *parser) endCompiler() *objFunction {
+	chunk := p.currentChunk()
+	end := len(chunk.Code)
+	if p.compiler.functionType == typeScript && p.lastExpression >= 0 && p.lastExpression == end-1 && p.compiler.lastJumpTarget != end {
+		// Return the value of a trailing expression statement instead of
+		// popping it. This can't be done if a jump lands after the pop,
+		// as the jump over the else branch of an if statement does, since
+		// the value is then not always on the stack.
+		chunk.Code = chunk.Code[:p.lastExpression]
+		chunk.Lines = chunk.Lines[:p.lastExpression]
+		p.emitOp(OpReturn)
+	} else {
+		p.emitReturn()
+	}
+	function := p.compiler.function
+
+	if debugPrintCode && len(p.errors) == 0 {
+		name := "<script>"
+		if function.name != nil {
+			name = function.name.chars
+		}
+		disassembleChunk(os.Stdout, chunk, name)
+	}
+
+	p.compiler = p.compiler.enclosing
+	return function
+}
+
+func (p *parser) beginScope() {
+	p.compiler.scopeDepth++
+}
+
+func (p *parser) endScope() {
+	c := p.compiler
+	c.scopeDepth--
+
+	for c.localCount > 0 && c.locals[c.localCount-1].depth > c.scopeDepth {
+		if c.locals[c.localCount-1].isCaptured {
+			p.emitOp(OpCloseUpvalue)
+		} else {
+			p.emitOp(OpPop)
+		}
+		c.localCount--
+	}
+}
+
+func (p *parser) identifierConstant(name *lox.Token) byte {
+	return p.makeConstant(objVal(p.strings.copyString(name.Lexeme)))
+}
+
+func identifiersEqual(a, b *lox.Token) bool {
+	return a.Lexeme == b.Lexeme
+}
+
+func (p *parser) resolveLocal(compiler *compiler, name *lox.Token) int {
+	for i := compiler.localCount - 1; i >= 0; i-- {
+		local := &compiler.locals[i]
+		if identifiersEqual(name, local.name) {
+			if local.depth == -1 {
+				p.error("Can't read local variable in its own initializer.")
+			}
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (p *parser) addUpvalue(compiler *compiler, index byte, isLocal bool) int {
+	upvalueCount := compiler.function.upvalueCount
+
+	for i := 0; i < upvalueCount; i++ {
+		upvalue := &compiler.upvalues[i]
+		if upvalue.index == index && upvalue.isLocal == isLocal {
+			return i
+		}
+	}
+
+	if upvalueCount == uint8Count {
+		p.error("Too many closure variables in function.")
+		return 0
+	}
+
+	compiler.upvalues[upvalueCount].isLocal = isLocal
+	compiler.upvalues[upvalueCount].index = index
+	compiler.function.upvalueCount++
+	return upvalueCount
+}
+
+func (p *parser) resolveUpvalue(compiler *compiler, name *lox.Token) int {
+	if compiler.enclosing == nil {
+		return -1
+	}
+
+	local := p.resolveLocal(compiler.enclosing, name)
+	if local != -1 {
+		compiler.enclosing.locals[local].isCaptured = true
+		return p.addUpvalue(compiler, byte(local), true)
+	}
+
+	upvalue := p.resolveUpvalue(compiler.enclosing, name)
+	if upvalue != -1 {
+		return p.addUpvalue(compiler, byte(upvalue), false)
+	}
+
+	return -1
+}
+
+func (p *parser) addLocal(name *lox.Token) {
+	c := p.compiler
+	if c.localCount == uint8Count {
+		p.error("Too many local variables in function.")
+		return
+	}
+
+	local := &c.locals[c.localCount]
+	c.localCount++
+	local.name = name
+	local.depth = -1
+	local.isCaptured = false
+}
+
+func (p *parser) declareVariable() {
+	c := p.compiler
+	if c.scopeDepth == 0 {
+		return
+	}
+
+	name := p.previous
+	for i := c.localCount - 1; i >= 0; i-- {
+		local := &c.locals[i]
+		if local.depth != -1 && local.depth < c.scopeDepth {
+			break
+		}
+
+		if identifiersEqual(name, local.name) {
+			p.error("Already a variable with this name in this scope.")
+		}
+	}
+
+	p.addLocal(name)
+}
+
+func (p *parser) parseVariable(errorMessage string) byte {
+	p.consume(lox.TokenTypeIdentifier, errorMessage)
+
+	p.declareVariable()
+	if p.compiler.scopeDepth > 0 {
+		return 0
+	}
+
+	return p.identifierConstant(p.previous)
+}
+
+func (p *parser) markInitialized() {
+	c := p.compiler
+	if c.scopeDepth == 0 {
+		return
+	}
+	c.locals[c.localCount-1].depth = c.scopeDepth
+}
+
+func (p *parser) defineVariable(global byte) {
+	if p.compiler.scopeDepth > 0 {
+		p.markInitialized()
+		return
+	}
+
+	p.emitBytes(byte(OpDefineGlobal), global)
+}
+
+func (p *parser) argumentList() byte {
+	argCount := 0
+	if !p.check(lox.TokenTypeRightParen) {
+		for {
+			p.expression()
+			if argCount == 255 {
+				p.error("Can't have more than 255 arguments.")
+			}
+			argCount++
+			if !p.match(lox.TokenTypeComma) {
+				break
+			}
+		}
+	}
+	p.consume(lox.TokenTypeRightParen, "Expect ')' after arguments.")
+	return byte(argCount)
+}
+
+func (p *parser) and(canAssign bool) {
+	endJump := p.emitJump(OpJumpIfFalse)
+
+	p.emitOp(OpPop)
+	p.parsePrecedence(precAnd)
+
+	p.patchJump(endJump)
+}
+
+func (p *parser) binary(canAssign bool) {
+	operatorType := p.previous.Type
+	rule := getRule(operatorType)
+	p.parsePrecedence(rule.precedence + 1)
+
+	switch operatorType {
+	case lox.TokenTypeBangEqual:
+		p.emitOps(OpEqual, OpNot)
+	case lox.TokenTypeEqualEqual:
+		p.emitOp(OpEqual)
+	case lox.TokenTypeGreater:
+		p.emitOp(OpGreater)
+	case lox.TokenTypeGreaterEqual:
+		p.emitOps(OpLess, OpNot)
+	case lox.TokenTypeLess:
+		p.emitOp(OpLess)
+	case lox.TokenTypeLessEqual:
+		p.emitOps(OpGreater, OpNot)
+	case lox.TokenTypePlus:
+		p.emitOp(OpAdd)
+	case lox.TokenTypeMinus:
+		p.emitOp(OpSubtract)
+	case lox.TokenTypeStar:
+		p.emitOp(OpMultiply)
+	case lox.TokenTypeSlash:
+		p.emitOp(OpDivide)
+	}
+}
+
+func (p *parser) call(canAssign bool) {
+	argCount := p.argumentList()
+	p.emitBytes(byte(OpCall), argCount)
+}
+
+func (p *parser) dot(canAssign bool) {
+	p.consume(lox.TokenTypeIdentifier, "Expect property name after '.'.")
+	name := p.identifierConstant(p.previous)
+
+	if canAssign && p.match(lox.TokenTypeEqual) {
+		p.expression()
+		p.emitBytes(byte(OpSetProperty), name)
+	} else if p.match(lox.TokenTypeLeftParen) {
+		argCount := p.argumentList()
+		p.emitBytes(byte(OpInvoke), name)
+		p.emitByte(argCount)
+	} else {
+		p.emitBytes(byte(OpGetProperty), name)
+	}
+}
+
+func (p *parser) literal(canAssign bool) {
+	switch p.previous.Type {
+	case lox.TokenTypeFalse:
+		p.emitOp(OpFalse)
+	case lox.TokenTypeNil:
+		p.emitOp(OpNil)
+	case lox.TokenTypeTrue:
+		p.emitOp(OpTrue)
+	}
+}
+
+func (p *parser) grouping(canAssign bool) {
+	p.expression()
+	p.consume(lox.TokenTypeRightParen, "Expect ')' after expression.")
+}
+
+func (p *parser) number(canAssign bool) {
+	value, _ := p.previous.Literal.(float64)
+	p.emitConstant(numberVal(value))
+}
+
+func (p *parser) or(canAssign bool) {
+	elseJump := p.emitJump(OpJumpIfFalse)
+	endJump := p.emitJump(OpJump)
+
+	p.patchJump(elseJump)
+	p.emitOp(OpPop)
+
+	p.parsePrecedence(precOr)
+	p.patchJump(endJump)
+}
+
+func (p *parser) string(canAssign bool) {
+	value, _ := p.previous.Literal.(string)
+	p.emitConstant(objVal(p.strings.copyString(value)))
+}
+
+func (p *parser) namedVariable(name *lox.Token, canAssign bool) {
+	var getOp, setOp OpCode
+	arg := p.resolveLocal(p.compiler, name)
+	if arg != -1 {
+		getOp = OpGetLocal
+		setOp = OpSetLocal
+	} else if arg = p.resolveUpvalue(p.compiler, name); arg != -1 {
+		getOp = OpGetUpvalue
+		setOp = OpSetUpvalue
+	} else {
+		arg = int(p.identifierConstant(name))
+		getOp = OpGetGlobal
+		setOp = OpSetGlobal
+	}
+
+	if canAssign && p.match(lox.TokenTypeEqual) {
+		p.expression()
+		p.emitBytes(byte(setOp), byte(arg))
+	} else {
+		p.emitBytes(byte(getOp), byte(arg))
+	}
+}
+
+func (p *parser) variable(canAssign bool) {
+	p.namedVariable(p.previous, canAssign)
+}
+
+func syntheticToken(text string) *lox.Token {
+	return lox.NewToken(lox.TokenTypeIdentifier, text, nil, 0)
+}
+
+func (p *parser) super(canAssign bool) {
+	if p.classCompiler == nil {
+		p.error("Can't use 'super' outside of a class.")
+	} else if !p.classCompiler.hasSuperclass {
+		p.error("Can't use 'super' in a class with no superclass.")
+	}
+
+	p.consume(lox.TokenTypeDot, "Expect '.' after 'super'.")
+	p.consume(lox.TokenTypeIdentifier, "Expect superclass method name.")
+	name := p.identifierConstant(p.previous)
+
+	p.namedVariable(syntheticToken("this"), false)
+	if p.match(lox.TokenTypeLeftParen) {
+		argCount := p.argumentList()
+		p.namedVariable(syntheticToken("super"), false)
+		p.emitBytes(byte(OpSuperInvoke), name)
+		p.emitByte(argCount)
+	} else {
+		p.namedVariable(syntheticToken("super"), false)
+		p.emitBytes(byte(OpGetSuper), name)
+	}
+}
+
+func (p *parser) this(canAssign bool) {
+	if p.classCompiler == nil {
+		p.error("Can't use 'this' outside of a class.")
+		return
+	}
+
+	p.variable(false)
+}
+
+func (p *parser) unary(canAssign bool) {
+	operatorType := p.previous.Type
+
+	// Compile the operand.
+	p.parsePrecedence(precUnary)
+
+	// Emit the operator instruction.
+	switch operatorType {
+	case lox.TokenTypeBang:
+		p.emitOp(OpNot)
+	case lox.TokenTypeMinus:
+		p.emitOp(OpNegate)
+	}
+}
+
+func (p *parser) parsePrecedence(precedence precedence) {
+	p.advance()
+	prefixRule := getRule(p.previous.Type).prefix
+	if prefixRule == nil {
+		p.error("Expect expression.")
+		return
+	}
+
+	canAssign := precedence <= precAssignment
+	prefixRule(p, canAssign)
+
+	for precedence <= getRule(p.current.Type).precedence {
+		p.advance()
+		infixRule := getRule(p.previous.Type).infix
+		infixRule(p, canAssign)
+	}
+
+	if canAssign && p.match(lox.TokenTypeEqual) {
+		p.error("Invalid assignment target.")
+	}
+}
+
+func getRule(tokenType lox.TokenType) *parseRule {
+	return &rules[tokenType]
+}
+
+func (p *parser) expression() {
+	p.parsePrecedence(precAssignment)
+}
+
+func (p *parser) block() {
+	for !p.check(lox.TokenTypeRightBrace) && !p.check(lox.TokenTypeEOF) {
+		p.declaration()
+	}
+
+	p.consume(lox.TokenTypeRightBrace, "Expect '}' after block.")
+}
+
+func (p *parser) function(functionType functionType) {
+	var compiler compiler
+	p.initCompiler(&compiler, functionType)
+	p.beginScope()
+
+	p.consume(lox.TokenTypeLeftParen, "Expect '(' after function name.")
+	if !p.check(lox.TokenTypeRightParen) {
+		for {
+			p.compiler.function.arity++
+			if p.compiler.function.arity > 255 {
+				p.errorAtCurrent("Can't have more than 255 parameters.")
+			}
+			constant := p.parseVariable("Expect parameter name.")
+			p.defineVariable(constant)
+			if !p.match(lox.TokenTypeComma) {
+				break
+			}
+		}
+	}
+	p.consume(lox.TokenTypeRightParen, "Expect ')' after parameters.")
+	p.consume(lox.TokenTypeLeftBrace, "Expect '{' before function body.")
+	p.block()
+
+	function := p.endCompiler()
+	p.emitBytes(byte(OpClosure), p.makeConstant(objVal(function)))
+
+	for i := 0; i < function.upvalueCount; i++ {
+		if compiler.upvalues[i].isLocal {
+			p.emitByte(1)
+		} else {
+			p.emitByte(0)
+		}
+		p.emitByte(compiler.upvalues[i].index)
+	}
+}
+
+func (p *parser) method() {
+	p.consume(lox.TokenTypeIdentifier, "Expect method name.")
+	constant := p.identifierConstant(p.previous)
+
+	functionType := typeMethod
+	if p.previous.Lexeme == "init" {
+		functionType = typeInitializer
+	}
+
+	p.function(functionType)
+	p.emitBytes(byte(OpMethod), constant)
+}
+
+func (p *parser) classDeclaration() {
+	p.consume(lox.TokenTypeIdentifier, "Expect class name.")
+	className := p.previous
+	nameConstant := p.identifierConstant(p.previous)
+	p.declareVariable()
+
+	p.emitBytes(byte(OpClass), nameConstant)
+	p.defineVariable(nameConstant)
+
+	classCompiler := &classCompiler{
+		enclosing:     p.classCompiler,
+		hasSuperclass: false,
+	}
+	p.classCompiler = classCompiler
+
+	if p.match(lox.TokenTypeLess) {
+		p.consume(lox.TokenTypeIdentifier, "Expect superclass name.")
+		p.variable(false)
+
+		if identifiersEqual(className, p.previous) {
+			p.error("A class can't inherit from itself.")
+		}
+
+		p.beginScope()
+		p.addLocal(syntheticToken("super"))
+		p.defineVariable(0)
+
+		p.namedVariable(className, false)
+		p.emitOp(OpInherit)
+		classCompiler.hasSuperclass = true
+	}
+
+	p.namedVariable(className, false)
+	p.consume(lox.TokenTypeLeftBrace, "Expect '{' before class body.")
+	for !p.check(lox.TokenTypeRightBrace) && !p.check(lox.TokenTypeEOF) {
+		p.method()
+	}
+	p.consume(lox.TokenTypeRightBrace, "Expect '}' after class body.")
+	p.emitOp(OpPop)
+
+	if classCompiler.hasSuperclass {
+		p.endScope()
+	}
+
+	p.classCompiler = p.classCompiler.enclosing
+}
+
+func (p *parser) funDeclaration() {
+	global := p.parseVariable("Expect function name.")
+	p.markInitialized()
+	p.function(typeFunction)
+	p.defineVariable(global)
+}
+
+func (p *parser) varDeclaration() {
+	global := p.parseVariable("Expect variable name.")
+
+	if p.match(lox.TokenTypeEqual) {
+		p.expression()
+	} else {
+		p.emitOp(OpNil)
+	}
+	p.consume(lox.TokenTypeSemicolon, "Expect ';' after variable declaration.")
+
+	p.defineVariable(global)
+}
+
+func (p *parser) expressionStatement() {
+	p.expression()
+	p.consume(lox.TokenTypeSemicolon, "Expect ';' after expression.")
+	p.emitOp(OpPop)
+	if p.compiler.functionType == typeScript && p.compiler.scopeDepth == 0 {
+		p.lastExpression = len(p.currentChunk().Code) - 1
+	}
+}
+
+func (p *parser) forStatement() {
+	p.beginScope()
+	p.consume(lox.TokenTypeLeftParen, "Expect '(' after 'for'.")
+	if p.match(lox.TokenTypeSemicolon) {
+		// No initializer.
+	} else if p.match(lox.TokenTypeVar) {
+		p.varDeclaration()
+	} else {
+		p.expressionStatement()
+	}
+
+	loopStart := len(p.currentChunk().Code)
+	exitJump := -1
+	if !p.match(lox.TokenTypeSemicolon) {
+		p.expression()
+		p.consume(lox.TokenTypeSemicolon, "Expect ';' after loop condition.")
+
+		// Jump out of the loop if the condition is false.
+		exitJump = p.emitJump(OpJumpIfFalse)
+		p.emitOp(OpPop)
+	}
+
+	if !p.match(lox.TokenTypeRightParen) {
+		bodyJump := p.emitJump(OpJump)
+		incrementStart := len(p.currentChunk().Code)
+		p.expression()
+		p.emitOp(OpPop)
+		p.consume(lox.TokenTypeRightParen, "Expect ')' after for clauses.")
+
+		p.emitLoop(loopStart)
+		loopStart = incrementStart
+		p.patchJump(bodyJump)
+	}
+
+	p.statement()
+	p.emitLoop(loopStart)
+
+	if exitJump != -1 {
+		p.patchJump(exitJump)
+		p.emitOp(OpPop)
+	}
+
+	p.endScope()
+}
+
+func (p *parser) ifStatement() {
+	p.consume(lox.TokenTypeLeftParen, "Expect '(' after 'if'.")
+	p.expression()
+	p.consume(lox.TokenTypeRightParen, "Expect ')' after condition.")
+
+	thenJump := p.emitJump(OpJumpIfFalse)
+	p.emitOp(OpPop)
+	p.statement()
+
+	elseJump := p.emitJump(OpJump)
+
+	p.patchJump(thenJump)
+	p.emitOp(OpPop)
+
+	if p.match(lox.TokenTypeElse) {
+		p.statement()
+	}
+	p.patchJump(elseJump)
+}
+
+func (p *parser) printStatement() {
+	p.expression()
+	p.consume(lox.TokenTypeSemicolon, "Expect ';' after value.")
+	p.emitOp(OpPrint)
+}
+
+func (p *parser) returnStatement() {
+	if p.compiler.functionType == typeScript {
+		p.error("Can't return from top-level code.")
+	}
+
+	if p.match(lox.TokenTypeSemicolon) {
+		p.emitReturn()
+	} else {
+		if p.compiler.functionType == typeInitializer {
+			p.error("Can't return a value from an initializer.")
+		}
+
+		p.expression()
+		p.consume(lox.TokenTypeSemicolon, "Expect ';' after return value.")
+		p.emitOp(OpReturn)
+	}
+}
+
+func (p *parser) whileStatement() {
+	loopStart := len(p.currentChunk().Code)
+	p.consume(lox.TokenTypeLeftParen, "Expect '(' after 'while'.")
+	p.expression()
+	p.consume(lox.TokenTypeRightParen, "Expect ')' after condition.")
+
+	exitJump := p.emitJump(OpJumpIfFalse)
+	p.emitOp(OpPop)
+	p.statement()
+	p.emitLoop(loopStart)
+
+	p.patchJump(exitJump)
+	p.emitOp(OpPop)
+}
+
+func (p *parser) synchronize() {
+	p.panicMode = false
+
+	for p.current.Type != lox.TokenTypeEOF {
+		if p.previous.Type == lox.TokenTypeSemicolon {
+			return
+		}
+		switch p.current.Type {
+		case lox.TokenTypeClass,
+			lox.TokenTypeFun,
+			lox.TokenTypeVar,
+			lox.TokenTypeFor,
+			lox.TokenTypeIf,
+			lox.TokenTypeWhile,
+			lox.TokenTypePrint,
+			lox.TokenTypeReturn:
+			return
+		}
+
+		p.advance()
+	}
+}
+
+func (p *parser) declaration() {
+	if p.match(lox.TokenTypeClass) {
+		p.classDeclaration()
+	} else if p.match(lox.TokenTypeFun) {
+		p.funDeclaration()
+	} else if p.match(lox.TokenTypeVar) {
+		p.varDeclaration()
+	} else {
+		p.statement()
+	}
+
+	if p.panicMode {
+		p.synchronize()
+	}
+}
+
+func (p *parser) statement() {
+	if p.match(lox.TokenTypePrint) {
+		p.printStatement()
+	} else if p.match(lox.TokenTypeFor) {
+		p.forStatement()
+	} else if p.match(lox.TokenTypeIf) {
+		p.ifStatement()
+	} else if p.match(lox.TokenTypeReturn) {
+		p.returnStatement()
+	} else if p.match(lox.TokenTypeWhile) {
+		p.whileStatement()
+	} else if p.match(lox.TokenTypeLeftBrace) {
+		p.beginScope()
+		p.block()
+		p.endScope()
+	} else {
+		p.expressionStatement()
+	}
+}
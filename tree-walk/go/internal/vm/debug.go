@@ -0,0 +1,140 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+)
+
+var opNames = map[OpCode]string{
+	OpConstant:     "OP_CONSTANT",
+	OpNil:          "OP_NIL",
+	OpTrue:         "OP_TRUE",
+	OpFalse:        "OP_FALSE",
+	OpPop:          "OP_POP",
+	OpGetLocal:     "OP_GET_LOCAL",
+	OpSetLocal:     "OP_SET_LOCAL",
+	OpGetGlobal:    "OP_GET_GLOBAL",
+	OpDefineGlobal: "OP_DEFINE_GLOBAL",
+	OpSetGlobal:    "OP_SET_GLOBAL",
+	OpGetUpvalue:   "OP_GET_UPVALUE",
+	OpSetUpvalue:   "OP_SET_UPVALUE",
+	OpGetProperty:  "OP_GET_PROPERTY",
+	OpSetProperty:  "OP_SET_PROPERTY",
+	OpGetSuper:     "OP_GET_SUPER",
+	OpEqual:        "OP_EQUAL",
+	OpGreater:      "OP_GREATER",
+	OpLess:         "OP_LESS",
+	OpAdd:          "OP_ADD",
+	OpSubtract:     "OP_SUBTRACT",
+	OpMultiply:     "OP_MULTIPLY",
+	OpDivide:       "OP_DIVIDE",
+	OpNot:          "OP_NOT",
+	OpNegate:       "OP_NEGATE",
+	OpPrint:        "OP_PRINT",
+	OpJump:         "OP_JUMP",
+	OpJumpIfFalse:  "OP_JUMP_IF_FALSE",
+	OpLoop:         "OP_LOOP",
+	OpCall:         "OP_CALL",
+	OpInvoke:       "OP_INVOKE",
+	OpSuperInvoke:  "OP_SUPER_INVOKE",
+	OpClosure:      "OP_CLOSURE",
+	OpCloseUpvalue: "OP_CLOSE_UPVALUE",
+	OpReturn:       "OP_RETURN",
+	OpClass:        "OP_CLASS",
+	OpInherit:      "OP_INHERIT",
+	OpMethod:       "OP_METHOD",
+}
+
+func (op OpCode) String() string {
+	if name, ok := opNames[op]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown opcode %d", byte(op))
+}
+
+func disassembleChunk(w io.Writer, chunk *Chunk, name string) {
+	fmt.Fprintf(w, "== %s ==\n", name)
+
+	for offset := 0; offset < len(chunk.Code); {
+		offset = disassembleInstruction(w, chunk, offset)
+	}
+}
+
+func disassembleInstruction(w io.Writer, chunk *Chunk, offset int) int {
+	fmt.Fprintf(w, "%04d", offset)
+	if offset > 0 && chunk.Lines[offset] == chunk.Lines[offset-1] {
+		fmt.Fprint(w, "   | ")
+	} else {
+		fmt.Fprintf(w, "%4d ", chunk.Lines[offset])
+	}
+
+	instruction := OpCode(chunk.Code[offset])
+	switch instruction {
+	case OpConstant, OpGetGlobal, OpDefineGlobal, OpSetGlobal,
+		OpGetProperty, OpSetProperty, OpGetSuper, OpClass, OpMethod:
+		return constantInstruction(w, instruction, chunk, offset)
+	case OpGetLocal, OpSetLocal, OpGetUpvalue, OpSetUpvalue, OpCall:
+		return byteInstruction(w, instruction, chunk, offset)
+	case OpJump, OpJumpIfFalse:
+		return jumpInstruction(w, instruction, 1, chunk, offset)
+	case OpLoop:
+		return jumpInstruction(w, instruction, -1, chunk, offset)
+	case OpInvoke, OpSuperInvoke:
+		return invokeInstruction(w, instruction, chunk, offset)
+	case OpClosure:
+		offset++
+		constant := chunk.Code[offset]
+		offset++
+		fmt.Fprintf(w, "%-16s %4d %v\n", instruction, constant, chunk.Constants[constant])
+
+		function, _ := chunk.Constants[constant].obj.(*objFunction)
+		for j := 0; j < function.upvalueCount; j++ {
+			isLocal := chunk.Code[offset]
+			index := chunk.Code[offset+1]
+			offset += 2
+			kind := "upvalue"
+			if isLocal == 1 {
+				kind = "local"
+			}
+			fmt.Fprintf(w, "%04d      |                     %s %d\n", offset-2, kind, index)
+		}
+		return offset
+	case OpNil, OpTrue, OpFalse, OpPop, OpEqual, OpGreater, OpLess,
+		OpAdd, OpSubtract, OpMultiply, OpDivide, OpNot, OpNegate, OpPrint,
+		OpCloseUpvalue, OpReturn, OpInherit:
+		return simpleInstruction(w, instruction, offset)
+	}
+
+	fmt.Fprintln(w, instruction)
+	return offset + 1
+}
+
+func simpleInstruction(w io.Writer, op OpCode, offset int) int {
+	fmt.Fprintln(w, op)
+	return offset + 1
+}
+
+func byteInstruction(w io.Writer, op OpCode, chunk *Chunk, offset int) int {
+	slot := chunk.Code[offset+1]
+	fmt.Fprintf(w, "%-16s %4d\n", op, slot)
+	return offset + 2
+}
+
+func jumpInstruction(w io.Writer, op OpCode, sign int, chunk *Chunk, offset int) int {
+	jump := int(chunk.Code[offset+1])<<8 | int(chunk.Code[offset+2])
+	fmt.Fprintf(w, "%-16s %4d -> %d\n", op, offset, offset+3+sign*jump)
+	return offset + 3
+}
+
+func constantInstruction(w io.Writer, op OpCode, chunk *Chunk, offset int) int {
+	constant := chunk.Code[offset+1]
+	fmt.Fprintf(w, "%-16s %4d '%v'\n", op, constant, chunk.Constants[constant])
+	return offset + 2
+}
+
+func invokeInstruction(w io.Writer, op OpCode, chunk *Chunk, offset int) int {
+	constant := chunk.Code[offset+1]
+	argCount := chunk.Code[offset+2]
+	fmt.Fprintf(w, "%-16s (%d args) %4d '%v'\n", op, argCount, constant, chunk.Constants[constant])
+	return offset + 3
+}
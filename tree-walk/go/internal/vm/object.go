@@ -0,0 +1,141 @@
+package vm
+
+import "fmt"
+
+type objString struct {
+	chars string
+}
+
+func (s *objString) String() string {
+	return s.chars
+}
+
+// stringTable interns every string the compiler and VM create so that equal
+// strings are always the same *objString and can be compared by pointer.
+type stringTable map[string]*objString
+
+func (t stringTable) copyString(chars string) *objString {
+	if interned, ok := t[chars]; ok {
+		return interned
+	}
+
+	s := &objString{chars: chars}
+	t[chars] = s
+	return s
+}
+
+type objFunction struct {
+	arity        int
+	upvalueCount int
+	chunk        Chunk
+	name         *objString
+}
+
+func newFunction() *objFunction {
+	return &objFunction{}
+}
+
+func (f *objFunction) String() string {
+	if f.name == nil {
+		return "<script>"
+	}
+	return fmt.Sprintf("<fn %s>", f.name.chars)
+}
+
+type nativeFn func(args []Value) Value
+
+type objNative struct {
+	function nativeFn
+}
+
+func newNative(function nativeFn) *objNative {
+	return &objNative{function: function}
+}
+
+func (n *objNative) String() string {
+	return "<native fn>"
+}
+
+type objUpvalue struct {
+	location *Value
+	closed   Value
+	// slot is the stack index location points at while the upvalue is open.
+	slot int
+	next *objUpvalue
+}
+
+func newUpvalue(location *Value, slot int) *objUpvalue {
+	return &objUpvalue{
+		location: location,
+		closed:   nilVal,
+		slot:     slot,
+	}
+}
+
+func (u *objUpvalue) String() string {
+	return "upvalue"
+}
+
+type objClosure struct {
+	function *objFunction
+	upvalues []*objUpvalue
+}
+
+func newClosure(function *objFunction) *objClosure {
+	return &objClosure{
+		function: function,
+		upvalues: make([]*objUpvalue, function.upvalueCount),
+	}
+}
+
+func (c *objClosure) String() string {
+	return c.function.String()
+}
+
+type objClass struct {
+	name    *objString
+	methods map[*objString]Value
+}
+
+func newClass(name *objString) *objClass {
+	return &objClass{
+		name:    name,
+		methods: make(map[*objString]Value),
+	}
+}
+
+func (c *objClass) String() string {
+	return c.name.chars
+}
+
+type objInstance struct {
+	class  *objClass
+	fields map[*objString]Value
+}
+
+func newInstance(class *objClass) *objInstance {
+	return &objInstance{
+		class:  class,
+		fields: make(map[*objString]Value),
+	}
+}
+
+func (i *objInstance) String() string {
+	return i.class.name.chars + " instance"
+}
+
+type objBoundMethod struct {
+	receiver Value
+	method   *objClosure
+}
+
+func newBoundMethod(receiver Value, method *objClosure) *objBoundMethod {
+	return &objBoundMethod{
+		receiver: receiver,
+		method:   method,
+	}
+}
+
+func (b *objBoundMethod) String() string {
+	return b.method.function.String()
+}
@@ -0,0 +1,110 @@
+package vm
+
+import "fmt"
+
+type valueType byte
+
+const (
+	valBool valueType = iota
+	valNil
+	valNumber
+	valObj
+)
+
+// Value is a Lox value on the VM stack. Numbers and booleans are stored
+// inline; everything else is a pointer to one of the obj types.
+type Value struct {
+	typ     valueType
+	boolean bool
+	number  float64
+	obj     any
+}
+
+var nilVal = Value{typ: valNil}
+
+func boolVal(b bool) Value {
+	return Value{typ: valBool, boolean: b}
+}
+
+func numberVal(n float64) Value {
+	return Value{typ: valNumber, number: n}
+}
+
+func objVal(obj any) Value {
+	return Value{typ: valObj, obj: obj}
+}
+
+func (v Value) isBool() bool {
+	return v.typ == valBool
+}
+
+func (v Value) isNil() bool {
+	return v.typ == valNil
+}
+
+func (v Value) isNumber() bool {
+	return v.typ == valNumber
+}
+
+func (v Value) isString() bool {
+	_, ok := v.obj.(*objString)
+	return ok
+}
+
+func (v Value) asString() *objString {
+	s, _ := v.obj.(*objString)
+	return s
+}
+
+func (v Value) isFalsey() bool {
+	return v.typ == valNil || (v.typ == valBool && !v.boolean)
+}
+
+// toAny converts v into the representation the tree-walking interpreter uses
+// for the same value.
+func (v Value) toAny() any {
+	switch v.typ {
+	case valBool:
+		return v.boolean
+	case valNumber:
+		return v.number
+	case valObj:
+		if s, ok := v.obj.(*objString); ok {
+			return s.chars
+		}
+		return v.obj
+	}
+	return nil
+}
+
+func (v Value) String() string {
+	switch v.typ {
+	case valBool:
+		if v.boolean {
+			return "true"
+		}
+		return "false"
+	case valNil:
+		return "nil"
+	case valNumber:
+		return fmt.Sprintf("%g", v.number)
+	}
+	return fmt.Sprintf("%v", v.obj)
+}
+
+func valuesEqual(a, b Value) bool {
+	if a.typ != b.typ {
+		return false
+	}
+	switch a.typ {
+	case valBool:
+		return a.boolean == b.boolean
+	case valNil:
+		return true
+	case valNumber:
+		return a.number == b.number
+	case valObj:
+		return a.obj == b.obj
+	}
+	return false // Unreachable.
+}
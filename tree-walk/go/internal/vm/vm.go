@@ -0,0 +1,501 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kashifsoofi/go-lox/internal/lox"
+)
+
+const (
+	debugTraceExecution = false
+	framesMax           = 64
+	stackMax            = framesMax * uint8Count
+)
+
+type callFrame struct {
+	closure *objClosure
+	ip      int
+	// slots is the index of the frame's first stack slot.
+	slots int
+}
+
+// VM executes Lox by compiling it to bytecode and running that on a stack
+// machine. Globals persist across calls to Run.
+type VM struct {
+	frames     [framesMax]callFrame
+	frameCount int
+
+	stack    [stackMax]Value
+	stackTop int
+
+	globals      map[*objString]Value
+	strings      stringTable
+	initString   *objString
+	openUpvalues *objUpvalue
+	stdout       io.Writer
+}
+
+// Option configures a VM created by NewVM.
+type Option func(vm *VM)
+
+// WithOutput sends the output of print statements to w instead of os.Stdout.
+func WithOutput(w io.Writer) Option {
+	return func(vm *VM) {
+		vm.stdout = w
+	}
+}
+
+func NewVM(options ...Option) *VM {
+	vm := &VM{
+		globals: make(map[*objString]Value),
+		strings: make(stringTable),
+		stdout:  os.Stdout,
+	}
+	vm.resetStack()
+	vm.initString = vm.strings.copyString("init")
+
+	// clock is the seconds since the Unix epoch, as in the tree-walker.
+	vm.defineNative("clock", func(args []Value) Value {
+		return numberVal(float64(time.Now().UnixNano()) / float64(time.Second))
+	})
+
+	for _, option := range options {
+		option(vm)
+	}
+	return vm
+}
+
+// RuntimeError is an error raised while executing bytecode, with the line
+// and function of every active call frame.
+type RuntimeError struct {
+	Message string
+	Trace   []string
+}
+
+func (e *RuntimeError) Error() string {
+	return e.Message + "\n" + strings.Join(e.Trace, "\n")
+}
+
+// Run compiles and executes source. Compile errors are returned as
+// diagnostics and nothing is executed; a runtime error is returned as a
+// *RuntimeError. The value is that of the final statement when it is a
+// top-level expression statement, and nil otherwise.
+func (vm *VM) Run(source string) (lox.Value, []lox.Diagnostic, error) {
+	function, diagnostics := compile(source, vm.strings)
+	if diagnostics != nil {
+		return nil, diagnostics, nil
+	}
+
+	vm.push(objVal(function))
+	closure := newClosure(function)
+	vm.pop()
+	vm.push(objVal(closure))
+	vm.call(closure, 0)
+
+	result, err := vm.run()
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.toAny(), nil, nil
+}
+
+func (vm *VM) resetStack() {
+	vm.stackTop = 0
+	vm.frameCount = 0
+	vm.openUpvalues = nil
+}
+
+func (vm *VM) runtimeError(format string, args ...any) error {
+	err := &RuntimeError{
+		Message: fmt.Sprintf(format, args...),
+		Trace:   make([]string, 0, vm.frameCount),
+	}
+
+	for i := vm.frameCount - 1; i >= 0; i-- {
+		frame := &vm.frames[i]
+		function := frame.closure.function
+		instruction := frame.ip - 1
+		line := function.chunk.Lines[instruction]
+		if function.name == nil {
+			err.Trace = append(err.Trace, fmt.Sprintf("[line %d] in script", line))
+		} else {
+			err.Trace = append(err.Trace, fmt.Sprintf("[line %d] in %s()", line, function.name.chars))
+		}
+	}
+
+	vm.resetStack()
+	return err
+}
+
+func (vm *VM) defineNative(name string, function nativeFn) {
+	vm.globals[vm.strings.copyString(name)] = objVal(newNative(function))
+}
+
+func (vm *VM) push(value Value) {
+	vm.stack[vm.stackTop] = value
+	vm.stackTop++
+}
+
+func (vm *VM) pop() Value {
+	vm.stackTop--
+	return vm.stack[vm.stackTop]
+}
+
+func (vm *VM) peek(distance int) Value {
+	return vm.stack[vm.stackTop-1-distance]
+}
+
+func (vm *VM) call(closure *objClosure, argCount int) error {
+	if argCount != closure.function.arity {
+		return vm.runtimeError("Expected %d arguments but got %d.", closure.function.arity, argCount)
+	}
+
+	if vm.frameCount == framesMax {
+		return vm.runtimeError("Stack overflow.")
+	}
+
+	frame := &vm.frames[vm.frameCount]
+	vm.frameCount++
+	frame.closure = closure
+	frame.ip = 0
+	frame.slots = vm.stackTop - argCount - 1
+	return nil
+}
+
+func (vm *VM) callValue(callee Value, argCount int) error {
+	switch callee := callee.obj.(type) {
+	case *objBoundMethod:
+		vm.stack[vm.stackTop-argCount-1] = callee.receiver
+		return vm.call(callee.method, argCount)
+	case *objClass:
+		vm.stack[vm.stackTop-argCount-1] = objVal(newInstance(callee))
+		if initializer, ok := callee.methods[vm.initString]; ok {
+			return vm.call(initializer.obj.(*objClosure), argCount)
+		} else if argCount != 0 {
+			return vm.runtimeError("Expected 0 arguments but got %d.", argCount)
+		}
+		return nil
+	case *objClosure:
+		return vm.call(callee, argCount)
+	case *objNative:
+		result := callee.function(vm.stack[vm.stackTop-argCount : vm.stackTop])
+		vm.stackTop -= argCount + 1
+		vm.push(result)
+		return nil
+	}
+	return vm.runtimeError("Can only call functions and classes.")
+}
+
+func (vm *VM) invokeFromClass(class *objClass, name *objString, argCount int) error {
+	method, ok := class.methods[name]
+	if !ok {
+		return vm.runtimeError("Undefined property '%s'.", name.chars)
+	}
+	return vm.call(method.obj.(*objClosure), argCount)
+}
+
+func (vm *VM) invoke(name *objString, argCount int) error {
+	receiver := vm.peek(argCount)
+
+	instance, ok := receiver.obj.(*objInstance)
+	if !ok {
+		return vm.runtimeError("Only instances have methods.")
+	}
+
+	if value, ok := instance.fields[name]; ok {
+		vm.stack[vm.stackTop-argCount-1] = value
+		return vm.callValue(value, argCount)
+	}
+
+	return vm.invokeFromClass(instance.class, name, argCount)
+}
+
+func (vm *VM) bindMethod(class *objClass, name *objString) error {
+	method, ok := class.methods[name]
+	if !ok {
+		return vm.runtimeError("Undefined property '%s'.", name.chars)
+	}
+
+	bound := newBoundMethod(vm.peek(0), method.obj.(*objClosure))
+	vm.pop()
+	vm.push(objVal(bound))
+	return nil
+}
+
+func (vm *VM) captureUpvalue(slot int) *objUpvalue {
+	var prevUpvalue *objUpvalue
+	upvalue := vm.openUpvalues
+	for upvalue != nil && upvalue.slot > slot {
+		prevUpvalue = upvalue
+		upvalue = upvalue.next
+	}
+
+	if upvalue != nil && upvalue.slot == slot {
+		return upvalue
+	}
+
+	createdUpvalue := newUpvalue(&vm.stack[slot], slot)
+	createdUpvalue.next = upvalue
+
+	if prevUpvalue == nil {
+		vm.openUpvalues = createdUpvalue
+	} else {
+		prevUpvalue.next = createdUpvalue
+	}
+
+	return createdUpvalue
+}
+
+func (vm *VM) closeUpvalues(last int) {
+	for vm.openUpvalues != nil && vm.openUpvalues.slot >= last {
+		upvalue := vm.openUpvalues
+		upvalue.closed = *upvalue.location
+		upvalue.location = &upvalue.closed
+		vm.openUpvalues = upvalue.next
+	}
+}
+
+func (vm *VM) defineMethod(name *objString) {
+	method := vm.peek(0)
+	class := vm.peek(1).obj.(*objClass)
+	class.methods[name] = method
+	vm.pop()
+}
+
+func (vm *VM) concatenate() {
+	b := vm.pop().asString()
+	a := vm.pop().asString()
+
+	result := vm.strings.copyString(a.chars + b.chars)
+	vm.push(objVal(result))
+}
+
+func (f *callFrame) readByte() byte {
+	b := f.closure.function.chunk.Code[f.ip]
+	f.ip++
+	return b
+}
+
+func (f *callFrame) readShort() int {
+	f.ip += 2
+	code := f.closure.function.chunk.Code
+	return int(code[f.ip-2])<<8 | int(code[f.ip-1])
+}
+
+func (f *callFrame) readConstant() Value {
+	return f.closure.function.chunk.Constants[f.readByte()]
+}
+
+func (f *callFrame) readString() *objString {
+	return f.readConstant().asString()
+}
+
+func (vm *VM) run() (Value, error) {
+	frame := &vm.frames[vm.frameCount-1]
+
+	for {
+		if debugTraceExecution {
+			fmt.Fprint(vm.stdout, "          ")
+			for slot := 0; slot < vm.stackTop; slot++ {
+				fmt.Fprintf(vm.stdout, "[ %v ]", vm.stack[slot])
+			}
+			fmt.Fprintln(vm.stdout)
+			disassembleInstruction(vm.stdout, &frame.closure.function.chunk, frame.ip)
+		}
+
+		switch instruction := OpCode(frame.readByte()); instruction {
+		case OpConstant:
+			vm.push(frame.readConstant())
+		case OpNil:
+			vm.push(nilVal)
+		case OpTrue:
+			vm.push(boolVal(true))
+		case OpFalse:
+			vm.push(boolVal(false))
+		case OpPop:
+			vm.pop()
+		case OpGetLocal:
+			slot := int(frame.readByte())
+			vm.push(vm.stack[frame.slots+slot])
+		case OpSetLocal:
+			slot := int(frame.readByte())
+			vm.stack[frame.slots+slot] = vm.peek(0)
+		case OpGetGlobal:
+			name := frame.readString()
+			value, ok := vm.globals[name]
+			if !ok {
+				return nilVal, vm.runtimeError("Undefined variable '%s'.", name.chars)
+			}
+			vm.push(value)
+		case OpDefineGlobal:
+			name := frame.readString()
+			vm.globals[name] = vm.peek(0)
+			vm.pop()
+		case OpSetGlobal:
+			name := frame.readString()
+			if _, ok := vm.globals[name]; !ok {
+				return nilVal, vm.runtimeError("Undefined variable '%s'.", name.chars)
+			}
+			vm.globals[name] = vm.peek(0)
+		case OpGetUpvalue:
+			slot := frame.readByte()
+			vm.push(*frame.closure.upvalues[slot].location)
+		case OpSetUpvalue:
+			slot := frame.readByte()
+			*frame.closure.upvalues[slot].location = vm.peek(0)
+		case OpGetProperty:
+			instance, ok := vm.peek(0).obj.(*objInstance)
+			if !ok {
+				return nilVal, vm.runtimeError("Only instances have properties.")
+			}
+
+			name := frame.readString()
+			if value, ok := instance.fields[name]; ok {
+				vm.pop() // Instance.
+				vm.push(value)
+				break
+			}
+
+			if err := vm.bindMethod(instance.class, name); err != nil {
+				return nilVal, err
+			}
+		case OpSetProperty:
+			instance, ok := vm.peek(1).obj.(*objInstance)
+			if !ok {
+				return nilVal, vm.runtimeError("Only instances have fields.")
+			}
+
+			instance.fields[frame.readString()] = vm.peek(0)
+			value := vm.pop()
+			vm.pop()
+			vm.push(value)
+		case OpGetSuper:
+			name := frame.readString()
+			superclass := vm.pop().obj.(*objClass)
+
+			if err := vm.bindMethod(superclass, name); err != nil {
+				return nilVal, err
+			}
+		case OpEqual:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(boolVal(valuesEqual(a, b)))
+		case OpGreater, OpLess, OpSubtract, OpMultiply, OpDivide:
+			if !vm.peek(0).isNumber() || !vm.peek(1).isNumber() {
+				return nilVal, vm.runtimeError("Operands must be numbers.")
+			}
+			b := vm.pop().number
+			a := vm.pop().number
+			switch instruction {
+			case OpGreater:
+				vm.push(boolVal(a > b))
+			case OpLess:
+				vm.push(boolVal(a < b))
+			case OpSubtract:
+				vm.push(numberVal(a - b))
+			case OpMultiply:
+				vm.push(numberVal(a * b))
+			case OpDivide:
+				vm.push(numberVal(a / b))
+			}
+		case OpAdd:
+			if vm.peek(0).isString() && vm.peek(1).isString() {
+				vm.concatenate()
+			} else if vm.peek(0).isNumber() && vm.peek(1).isNumber() {
+				b := vm.pop().number
+				a := vm.pop().number
+				vm.push(numberVal(a + b))
+			} else {
+				return nilVal, vm.runtimeError("Operands must be two numbers or two strings.")
+			}
+		case OpNot:
+			vm.push(boolVal(vm.pop().isFalsey()))
+		case OpNegate:
+			if !vm.peek(0).isNumber() {
+				return nilVal, vm.runtimeError("Operand must be a number.")
+			}
+			vm.push(numberVal(-vm.pop().number))
+		case OpPrint:
+			fmt.Fprintln(vm.stdout, vm.pop())
+		case OpJump:
+			offset := frame.readShort()
+			frame.ip += offset
+		case OpJumpIfFalse:
+			offset := frame.readShort()
+			if vm.peek(0).isFalsey() {
+				frame.ip += offset
+			}
+		case OpLoop:
+			offset := frame.readShort()
+			frame.ip -= offset
+		case OpCall:
+			argCount := int(frame.readByte())
+			if err := vm.callValue(vm.peek(argCount), argCount); err != nil {
+				return nilVal, err
+			}
+			frame = &vm.frames[vm.frameCount-1]
+		case OpInvoke:
+			method := frame.readString()
+			argCount := int(frame.readByte())
+			if err := vm.invoke(method, argCount); err != nil {
+				return nilVal, err
+			}
+			frame = &vm.frames[vm.frameCount-1]
+		case OpSuperInvoke:
+			method := frame.readString()
+			argCount := int(frame.readByte())
+			superclass := vm.pop().obj.(*objClass)
+			if err := vm.invokeFromClass(superclass, method, argCount); err != nil {
+				return nilVal, err
+			}
+			frame = &vm.frames[vm.frameCount-1]
+		case OpClosure:
+			function := frame.readConstant().obj.(*objFunction)
+			closure := newClosure(function)
+			vm.push(objVal(closure))
+			for i := range closure.upvalues {
+				isLocal := frame.readByte()
+				index := int(frame.readByte())
+				if isLocal == 1 {
+					closure.upvalues[i] = vm.captureUpvalue(frame.slots + index)
+				} else {
+					closure.upvalues[i] = frame.closure.upvalues[index]
+				}
+			}
+		case OpCloseUpvalue:
+			vm.closeUpvalues(vm.stackTop - 1)
+			vm.pop()
+		case OpReturn:
+			result := vm.pop()
+			vm.closeUpvalues(frame.slots)
+			vm.frameCount--
+			if vm.frameCount == 0 {
+				vm.pop()
+				return result, nil
+			}
+
+			vm.stackTop = frame.slots
+			vm.push(result)
+			frame = &vm.frames[vm.frameCount-1]
+		case OpClass:
+			vm.push(objVal(newClass(frame.readString())))
+		case OpInherit:
+			superclass, ok := vm.peek(1).obj.(*objClass)
+			if !ok {
+				return nilVal, vm.runtimeError("Superclass must be a class.")
+			}
+
+			subclass := vm.peek(0).obj.(*objClass)
+			for name, method := range superclass.methods {
+				subclass.methods[name] = method
+			}
+			vm.pop() // Subclass.
+		case OpMethod:
+			vm.defineMethod(frame.readString())
+		}
+	}
+}